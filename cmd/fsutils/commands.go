@@ -0,0 +1,870 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp/syntax"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/utsav-56/go_fs_utils/fsutils"
+)
+
+func runCp(ctx context.Context, e *env, flags *flag.FlagSet, args []string) error {
+	clone := flags.String("clone", "auto", "copy-on-write `policy`: auto, always or never")
+	hardlinks := flags.Bool("preserve-hardlinks", false, "recreate hardlinks between copied files")
+	workers := flags.Int("workers", 0, "number of files copied concurrently (default: number of CPUs)")
+	keepGoing := flags.Bool("continue-on-error", false, "skip paths that cannot be copied and copy the rest")
+	showProgress := flags.Bool("progress", false, "show progress on stderr")
+	var quota fsutils.Size
+	flags.Var(&quota, "quota", "fail instead of copying more than this `size`, such as 10G")
+	ignore := ignoreFlags(flags)
+	backup := registerBackupFlags(flags)
+	conflict := registerConflictFlags(flags)
+	args, err := e.parse(flags, args, 2, -1)
+	if err != nil {
+		return err
+	}
+	opts := fsutils.CopyOptions{
+		PreserveHardlinks: *hardlinks,
+		Workers:           *workers,
+		ContinueOnError:   *keepGoing,
+		PreScan:           *showProgress || quota > 0,
+		Quota:             quota,
+		Ignore:            ignore.matcher(),
+		IgnoreFile:        ignore.file,
+	}
+	if opts.Clone, err = parseClone(*clone); err != nil {
+		return err
+	}
+	if opts.Backup, err = backup.options(); err != nil {
+		return err
+	}
+	if opts.Conflict, err = conflict.options(); err != nil {
+		return err
+	}
+	progress, done := e.progress(*showProgress)
+	defer done()
+	opts.Progress = progress
+
+	srcs, dst := args[:len(args)-1], args[len(args)-1]
+	result, err := fsutils.CopyPaths(ctx, srcs, dst, opts)
+	if e.json {
+		e.printJSON(map[string]interface{}{
+			"files":     result.Files,
+			"dirs":      result.Dirs,
+			"bytes":     result.Bytes,
+			"hardlinks": result.Hardlinks,
+			"failed":    result.Failed,
+		})
+	}
+	return err
+}
+
+// parseClone maps the --clone flag to a CloneMode.
+func parseClone(value string) (fsutils.CloneMode, error) {
+	switch value {
+	case "auto":
+		return fsutils.CloneAuto, nil
+	case "always":
+		return fsutils.CloneRequire, nil
+	case "never":
+		return fsutils.CloneForbid, nil
+	}
+	return 0, usageError{fmt.Sprintf("invalid --clone %q", value)}
+}
+
+func runMv(ctx context.Context, e *env, flags *flag.FlagSet, args []string) error {
+	showProgress := flags.Bool("progress", false, "show progress on stderr when copying across filesystems")
+	backup := registerBackupFlags(flags)
+	conflict := registerConflictFlags(flags)
+	args, err := e.parse(flags, args, 2, -1)
+	if err != nil {
+		return err
+	}
+	progress, done := e.progress(*showProgress)
+	defer done()
+	opts := fsutils.CopyOptions{Progress: progress, PreScan: *showProgress}
+	if opts.Backup, err = backup.options(); err != nil {
+		return err
+	}
+	if opts.Conflict, err = conflict.options(); err != nil {
+		return err
+	}
+	srcs, dst := args[:len(args)-1], args[len(args)-1]
+	if err := fsutils.MovePaths(ctx, srcs, dst, opts); err != nil || !e.json {
+		return err
+	}
+	return e.printJSON(map[string]interface{}{"sources": srcs, "dst": dst})
+}
+
+func runRm(ctx context.Context, e *env, flags *flag.FlagSet, args []string) error {
+	recursive := flags.Bool("r", false, "remove directories and their contents")
+	force := flags.Bool("f", false, "ignore paths that do not exist")
+	keepGoing := flags.Bool("continue-on-error", false, "keep removing the rest of a tree when an entry cannot be removed")
+	showProgress := flags.Bool("progress", false, "show progress on stderr")
+	trash := flags.Bool("trash", false, "move paths to the trash instead of deleting them")
+	dryRun := flags.Bool("dry-run", false, "list what would be removed without removing anything")
+	unsafe := flags.Bool("no-preserve-root", false, "allow removing /, the home directory, mount points and the current directory")
+	var roots patternList
+	flags.Var(&roots, "allowed-root", "only remove paths inside `dir` (repeatable)")
+	args, err := e.parse(flags, args, 1, -1)
+	if err != nil {
+		return err
+	}
+	progress, done := e.progress(*showProgress)
+	defer done()
+	guard := fsutils.GuardOptions{AllowedRoots: roots, Unsafe: *unsafe}
+
+	removed := []string{}
+	for _, path := range args {
+		info, err := os.Lstat(path)
+		if os.IsNotExist(err) && *force {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && !*recursive {
+			return fmt.Errorf("'%s' is a directory, use -r", path)
+		}
+		if err := fsutils.CheckRemovable(path, guard); err != nil {
+			return err
+		}
+		switch {
+		case *trash && *dryRun:
+			removed = append(removed, path)
+		case *trash:
+			_, err = fsutils.MoveToTrash(path)
+		case info.IsDir():
+			opts := fsutils.RemoveOptions{Progress: progress, PreScan: *showProgress, ContinueOnError: *keepGoing, Guard: guard, DryRun: *dryRun}
+			if *dryRun {
+				opts.OnRemove = func(path string) { removed = append(removed, path) }
+			}
+			err = fsutils.RmDirContext(ctx, path, opts)
+		case *dryRun:
+			removed = append(removed, path)
+		default:
+			err = os.Remove(path)
+		}
+		if err != nil {
+			return err
+		}
+		if !*dryRun {
+			removed = append(removed, path)
+		}
+	}
+	switch {
+	case e.json:
+		return e.printJSON(map[string][]string{"removed": removed})
+	case *dryRun:
+		for _, path := range removed {
+			fmt.Fprintln(e.stdout, path)
+		}
+	}
+	return nil
+}
+
+func runMkdir(ctx context.Context, e *env, flags *flag.FlagSet, args []string) error {
+	args, err := e.parse(flags, args, 1, -1)
+	if err != nil {
+		return err
+	}
+	for _, path := range args {
+		if err := fsutils.Mkdir(path); err != nil {
+			return err
+		}
+	}
+	if e.json {
+		return e.printJSON(map[string][]string{"created": args})
+	}
+	return nil
+}
+
+func runLs(ctx context.Context, e *env, flags *flag.FlagSet, args []string) error {
+	all := flags.Bool("a", false, "include entries whose names start with a dot")
+	long := flags.Bool("l", false, "show mode, size and modification time")
+	format := formatFlag(flags)
+	args, err := e.parse(flags, args, 0, -1)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		args = []string{"."}
+	}
+	records, err := e.recordWriter(*format)
+	if err != nil {
+		return err
+	}
+	if records == nil && *long {
+		records = fsutils.NewRecordWriter(e.stdout, fsutils.FormatTable)
+	}
+
+	for i, dir := range args {
+		var listing []fsutils.FileRecord
+		rec, err := fsutils.StatRecord(dir)
+		if err == nil && rec.Type == "symlink" && fsutils.DirExists(dir) {
+			rec.Type = "dir"
+		}
+		switch {
+		case err != nil:
+			return err
+		case rec.Type != "dir":
+			listing = append(listing, rec)
+		default:
+			names, err := fsutils.GetList(dir)
+			if err != nil {
+				return err
+			}
+			for _, name := range names {
+				if !*all && strings.HasPrefix(name, ".") {
+					continue
+				}
+				rec, err := fsutils.StatRecord(filepath.Join(dir, name))
+				if os.IsNotExist(err) {
+					continue
+				}
+				if err != nil {
+					return err
+				}
+				listing = append(listing, rec)
+			}
+		}
+		if records != nil {
+			for _, rec := range listing {
+				if err := records.Write(rec); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if len(args) > 1 {
+			if i > 0 {
+				fmt.Fprintln(e.stdout)
+			}
+			fmt.Fprintf(e.stdout, "%s:\n", dir)
+		}
+		for _, rec := range listing {
+			fmt.Fprintln(e.stdout, rec.Name)
+		}
+	}
+	if records != nil {
+		return records.Flush()
+	}
+	return nil
+}
+
+func runInfo(ctx context.Context, e *env, flags *flag.FlagSet, args []string) error {
+	format := formatFlag(flags)
+	args, err := e.parse(flags, args, 1, -1)
+	if err != nil {
+		return err
+	}
+	records, err := e.recordWriter(*format)
+	if err != nil {
+		return err
+	}
+	for i, path := range args {
+		if records != nil {
+			rec, err := fsutils.StatRecord(path)
+			if err != nil {
+				return err
+			}
+			if err := records.Write(rec); err != nil {
+				return err
+			}
+			continue
+		}
+		// PathInfo only prints a warning for missing paths.
+		if _, err := os.Stat(path); err != nil {
+			return err
+		}
+		info := fsutils.PathInfo(path)
+		if i > 0 {
+			fmt.Fprintln(e.stdout)
+		}
+		fmt.Fprintf(e.stdout, "%s:\n", path)
+		keys := make([]string, 0, len(info))
+		for key := range info {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			fmt.Fprintf(e.stdout, "  %-13s %v\n", key+":", info[key])
+		}
+	}
+	if records != nil {
+		return records.Flush()
+	}
+	return nil
+}
+
+func runDu(ctx context.Context, e *env, flags *flag.FlagSet, args []string) error {
+	allocated := flags.Bool("allocated", false, "show bytes allocated on disk instead of apparent sizes")
+	human := flags.Bool("human", false, "show sizes in binary units such as KiB and MiB")
+	si := flags.Bool("si", false, "show sizes in decimal units such as kB and MB")
+	args, err := e.parse(flags, args, 0, -1)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		args = []string{"."}
+	}
+	type usage struct {
+		Path      string `json:"path"`
+		Files     int    `json:"files"`
+		Dirs      int    `json:"dirs"`
+		Bytes     int64  `json:"bytes"`
+		Allocated int64  `json:"allocated"`
+	}
+	usages := []usage{}
+	for _, path := range args {
+		u, err := fsutils.DiskUsage(ctx, path)
+		if err != nil {
+			return err
+		}
+		if e.json {
+			usages = append(usages, usage{path, u.Files, u.Dirs, u.Bytes, u.Allocated})
+			continue
+		}
+		size := u.Bytes
+		if *allocated {
+			size = u.Allocated
+		}
+		switch {
+		case *human:
+			fmt.Fprintf(e.stdout, "%s\t%s\n", fsutils.Size(size), path)
+		case *si:
+			fmt.Fprintf(e.stdout, "%s\t%s\n", fsutils.Size(size).SI(), path)
+		default:
+			fmt.Fprintf(e.stdout, "%d\t%s\n", size, path)
+		}
+	}
+	if e.json {
+		return e.printJSON(usages)
+	}
+	return nil
+}
+
+func runFind(ctx context.Context, e *env, flags *flag.FlagSet, args []string) error {
+	name := flags.String("name", "", "only show entries whose base name matches the glob `pattern`")
+	kind := flags.String("type", "", "only show entries of this `type`: f (file), d (directory) or l (symlink)")
+	maxDepth := flags.Int("maxdepth", 0, "descend at most this many levels (0: no limit)")
+	minDepth := flags.Int("mindepth", 0, "skip entries above this depth")
+	follow := flags.Bool("L", false, "follow symbolic links")
+	var minSize, maxSize fsutils.Size
+	flags.Var(&minSize, "min-size", "only show files of at least this `size`, such as 1M")
+	flags.Var(&maxSize, "max-size", "only show files of at most this `size`")
+	where := flags.String("where", "", "only show entries matching the `query`, such as 'ext:go and mtime<7d'")
+	ignore := ignoreFlags(flags)
+	format := formatFlag(flags)
+	args, err := e.parse(flags, args, 0, -1)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		args = []string{"."}
+	}
+	if _, err := filepath.Match(*name, ""); err != nil {
+		return usageError{fmt.Sprintf("invalid --name %q: %v", *name, err)}
+	}
+	types := map[string]func(fs.FileMode) bool{
+		"":  func(fs.FileMode) bool { return true },
+		"f": fs.FileMode.IsRegular,
+		"d": fs.FileMode.IsDir,
+		"l": func(mode fs.FileMode) bool { return mode&fs.ModeSymlink != 0 },
+	}
+	isType, ok := types[*kind]
+	if !ok {
+		return usageError{fmt.Sprintf("invalid --type %q", *kind)}
+	}
+	query := func(fsutils.WalkEntry) bool { return true }
+	if *where != "" {
+		if query, err = fsutils.ParseQuery(*where); err != nil {
+			return usageError{err.Error()}
+		}
+	}
+	records, err := e.recordWriter(*format)
+	if err != nil {
+		return err
+	}
+
+	opts := fsutils.WalkOptions{
+		MaxDepth:       *maxDepth,
+		FollowSymlinks: *follow,
+		MinSize:        minSize,
+		MaxSize:        maxSize,
+		Ignore:         ignore.matcher(),
+		IgnoreFile:     ignore.file,
+		Filter: func(entry fsutils.WalkEntry) bool {
+			if entry.Depth < *minDepth || !isType(entry.Info.Mode()) || !query(entry) {
+				return false
+			}
+			if *name == "" {
+				return true
+			}
+			matched, _ := filepath.Match(*name, entry.Info.Name())
+			return matched
+		},
+	}
+	for _, root := range args {
+		err := fsutils.Walk(ctx, root, opts, func(entry fsutils.WalkEntry) error {
+			if records != nil {
+				return records.Write(fsutils.NewFileRecord(entry.Path, entry.Info))
+			}
+			_, err := fmt.Fprintln(e.stdout, entry.Path)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if records != nil {
+		return records.Flush()
+	}
+	return nil
+}
+
+func runTree(ctx context.Context, e *env, flags *flag.FlagSet, args []string) error {
+	var opts fsutils.TreeOptions
+	flags.BoolVar(&opts.ShowHidden, "a", false, "include entries whose names start with a dot")
+	flags.IntVar(&opts.MaxDepth, "maxdepth", 0, "descend at most this many levels (0: no limit)")
+	flags.BoolVar(&opts.DirsFirst, "dirsfirst", false, "list directories before files")
+	flags.BoolVar(&opts.ASCII, "ascii", false, "draw branches with ASCII characters")
+	flags.BoolVar(&opts.ShowSize, "s", false, "show sizes")
+	flags.BoolVar(&opts.ShowMode, "p", false, "show permissions")
+	flags.BoolVar(&opts.ShowModTime, "D", false, "show modification times")
+	name := flags.String("name", "", "only show files whose base name matches the glob `pattern`")
+	args, err := e.parse(flags, args, 0, 1)
+	if err != nil {
+		return err
+	}
+	root := "."
+	if len(args) == 1 {
+		root = args[0]
+	}
+	if _, err := filepath.Match(*name, ""); err != nil {
+		return usageError{fmt.Sprintf("invalid --name %q: %v", *name, err)}
+	}
+	if *name != "" {
+		opts.Filter = func(path string, info fs.FileInfo) bool {
+			matched, _ := filepath.Match(*name, info.Name())
+			return matched || info.IsDir()
+		}
+	}
+
+	node, err := fsutils.ReadTree(root, opts)
+	if err != nil {
+		return err
+	}
+	if e.json {
+		return e.printJSON(node)
+	}
+	dirs, files := node.Count()
+	fmt.Fprint(e.stdout, node.Render(opts))
+	fmt.Fprintf(e.stdout, "\n%d directories, %d files\n", dirs, files)
+	return nil
+}
+
+func runHash(ctx context.Context, e *env, flags *flag.FlagSet, args []string) error {
+	keepGoing := flags.Bool("continue-on-error", false, "skip files that cannot be read and hash the rest")
+	ignore := ignoreFlags(flags)
+	args, err := e.parse(flags, args, 1, -1)
+	if err != nil {
+		return err
+	}
+	type checksum struct {
+		Path   string `json:"path"`
+		SHA256 string `json:"sha256"`
+	}
+	sums := []checksum{}
+	var partial *fsutils.MultiError
+	for _, path := range args {
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			sum, err := fsutils.HashFileContext(ctx, path, fsutils.HashOptions{})
+			if err != nil {
+				return err
+			}
+			sums = append(sums, checksum{path, sum})
+			continue
+		}
+		opts := fsutils.HashOptions{ContinueOnError: *keepGoing, Ignore: ignore.matcher(), IgnoreFile: ignore.file}
+		dirSums, err := fsutils.HashDir(ctx, path, opts)
+		if err != nil && dirSums == nil {
+			return err
+		}
+		var multi *fsutils.MultiError
+		if errors.As(err, &multi) {
+			partial = mergeFailures(partial, multi)
+		}
+		rels := make([]string, 0, len(dirSums))
+		for rel := range dirSums {
+			rels = append(rels, rel)
+		}
+		sort.Strings(rels)
+		for _, rel := range rels {
+			sums = append(sums, checksum{filepath.Join(path, filepath.FromSlash(rel)), dirSums[rel]})
+		}
+	}
+	if e.json {
+		if err := e.printJSON(sums); err != nil {
+			return err
+		}
+	} else {
+		for _, sum := range sums {
+			fmt.Fprintf(e.stdout, "%s  %s\n", sum.SHA256, sum.Path)
+		}
+	}
+	if partial != nil {
+		partial.Succeeded = len(sums)
+		return partial
+	}
+	return nil
+}
+
+func runSync(ctx context.Context, e *env, flags *flag.FlagSet, args []string) error {
+	del := flags.Bool("delete", false, "remove entries from DST that are not in SRC")
+	checksum := flags.Bool("checksum", false, "compare file contents instead of sizes and modification times")
+	dryRun := flags.Bool("dry-run", false, "show what would change without changing anything")
+	verbose := flags.Bool("v", false, "list the paths copied and deleted")
+	keepGoing := flags.Bool("continue-on-error", false, "skip paths that cannot be synchronised and do the rest")
+	showProgress := flags.Bool("progress", false, "show progress on stderr")
+	ignore := ignoreFlags(flags)
+	args, err := e.parse(flags, args, 2, 2)
+	if err != nil {
+		return err
+	}
+	progress, done := e.progress(*showProgress)
+	opts := fsutils.SyncOptions{
+		Delete:          *del,
+		Checksum:        *checksum,
+		DryRun:          *dryRun,
+		Progress:        progress,
+		ContinueOnError: *keepGoing,
+		Ignore:          ignore.matcher(),
+		IgnoreFile:      ignore.file,
+	}
+	result, err := fsutils.SyncDir(ctx, args[0], args[1], opts)
+	done()
+	if e.json {
+		e.printJSON(map[string]interface{}{
+			"copied":  nonNil(result.Copied),
+			"deleted": nonNil(result.Deleted),
+			"bytes":   result.Bytes,
+			"dryRun":  *dryRun,
+		})
+		return err
+	}
+	if *verbose || *dryRun {
+		for _, path := range result.Copied {
+			fmt.Fprintf(e.stdout, "copy %s\n", path)
+		}
+		for _, path := range result.Deleted {
+			fmt.Fprintf(e.stdout, "delete %s\n", path)
+		}
+	}
+	return err
+}
+
+func runDiff(ctx context.Context, e *env, flags *flag.FlagSet, args []string) error {
+	checksum := flags.Bool("checksum", false, "compare file contents instead of sizes and modification times")
+	ignore := ignoreFlags(flags)
+	args, err := e.parse(flags, args, 2, 2)
+	if err != nil {
+		return err
+	}
+	opts := fsutils.DiffOptions{Checksum: *checksum, Ignore: ignore.matcher(), IgnoreFile: ignore.file}
+	diffs, err := fsutils.DiffDirs(ctx, args[0], args[1], opts)
+	if err != nil {
+		return err
+	}
+	if e.json {
+		type difference struct {
+			Path string           `json:"path"`
+			Kind fsutils.DiffKind `json:"kind"`
+		}
+		out := []difference{}
+		for _, d := range diffs {
+			out = append(out, difference{d.Path, d.Kind})
+		}
+		if err := e.printJSON(out); err != nil {
+			return err
+		}
+	} else {
+		marks := map[fsutils.DiffKind]string{
+			fsutils.DiffAdded:       "A",
+			fsutils.DiffRemoved:     "D",
+			fsutils.DiffModified:    "M",
+			fsutils.DiffTypeChanged: "T",
+		}
+		for _, d := range diffs {
+			fmt.Fprintf(e.stdout, "%s %s\n", marks[d.Kind], d.Path)
+		}
+	}
+	if len(diffs) > 0 {
+		return errDiffer
+	}
+	return nil
+}
+
+func runGrep(ctx context.Context, e *env, flags *flag.FlagSet, args []string) error {
+	literal := flags.Bool("F", false, "treat the pattern as a literal string")
+	ignoreCase := flags.Bool("i", false, "ignore case")
+	after := flags.Int("A", 0, "print `n` lines of context after each match")
+	before := flags.Int("B", 0, "print `n` lines of context before each match")
+	around := flags.Int("C", 0, "print `n` lines of context around each match")
+	maxCount := flags.Int("m", 0, "stop reading a file after `n` matching lines (0: no limit)")
+	filesOnly := flags.Bool("l", false, "only print the names of files with matches")
+	column := flags.Bool("column", false, "print the column of the first match on each line")
+	where := flags.String("where", "", "only search files matching the `query`, such as 'ext:go'")
+	maxDepth := flags.Int("maxdepth", 0, "descend at most this many levels (0: no limit)")
+	follow := flags.Bool("L", false, "follow symbolic links")
+	workers := flags.Int("workers", 0, "number of files searched concurrently (default: number of CPUs)")
+	keepGoing := flags.Bool("continue-on-error", false, "skip paths that cannot be read and search the rest")
+	ignore := ignoreFlags(flags)
+	args, err := e.parse(flags, args, 1, -1)
+	if err != nil {
+		return err
+	}
+	pattern, roots := args[0], args[1:]
+	if len(roots) == 0 {
+		roots = []string{"."}
+	}
+	opts := fsutils.GrepOptions{
+		Literal:    *literal,
+		IgnoreCase: *ignoreCase,
+		Before:     *before,
+		After:      *after,
+		MaxCount:   *maxCount,
+		Workers:    *workers,
+		Walk: fsutils.WalkOptions{
+			MaxDepth:        *maxDepth,
+			FollowSymlinks:  *follow,
+			ContinueOnError: *keepGoing,
+			Ignore:          ignore.matcher(),
+			IgnoreFile:      ignore.file,
+		},
+	}
+	if *around > 0 {
+		opts.Before, opts.After = *around, *around
+	}
+	if *where != "" {
+		query, err := fsutils.ParseQuery(*where)
+		if err != nil {
+			return usageError{err.Error()}
+		}
+		opts.Walk.Filter = query
+	}
+
+	matches := []fsutils.GrepMatch{}
+	var partial *fsutils.MultiError
+	for _, root := range roots {
+		found, err := fsutils.Grep(ctx, root, pattern, opts)
+		var invalid *syntax.Error
+		var multi *fsutils.MultiError
+		switch {
+		case errors.As(err, &invalid):
+			return usageError{err.Error()}
+		case err != nil && !errors.As(err, &multi):
+			return err
+		}
+		matches = append(matches, found...)
+		if multi != nil {
+			partial = mergeFailures(partial, multi)
+		}
+	}
+	switch {
+	case e.json:
+		if err := e.printJSON(matches); err != nil {
+			return err
+		}
+	case *filesOnly:
+		for i, m := range matches {
+			if i == 0 || m.Path != matches[i-1].Path {
+				fmt.Fprintln(e.stdout, m.Path)
+			}
+		}
+	default:
+		printGrep(e.stdout, matches, *column, opts.Before > 0 || opts.After > 0)
+	}
+	if partial != nil {
+		return partial
+	}
+	if len(matches) == 0 {
+		return errDiffer
+	}
+	return nil
+}
+
+// printGrep prints matches like grep -n: "path:line:text" for matching lines
+// and "path-line-text" for context lines, with "--" between groups of lines
+// that are not adjacent.
+func printGrep(w io.Writer, matches []fsutils.GrepMatch, column, separate bool) {
+	for start := 0; start < len(matches); {
+		end := start
+		for end < len(matches) && matches[end].Path == matches[start].Path {
+			end++
+		}
+		lines := map[int]string{}
+		hits := map[int]fsutils.GrepMatch{}
+		for _, m := range matches[start:end] {
+			for i, text := range m.Before {
+				lines[m.Line-len(m.Before)+i] = text
+			}
+			for i, text := range m.After {
+				lines[m.Line+1+i] = text
+			}
+			lines[m.Line] = m.Text
+			hits[m.Line] = m
+		}
+		numbers := make([]int, 0, len(lines))
+		for n := range lines {
+			numbers = append(numbers, n)
+		}
+		sort.Ints(numbers)
+
+		path := matches[start].Path
+		for i, n := range numbers {
+			if separate && (i > 0 && n > numbers[i-1]+1 || i == 0 && start > 0) {
+				fmt.Fprintln(w, "--")
+			}
+			m, hit := hits[n]
+			switch {
+			case !hit:
+				fmt.Fprintf(w, "%s-%d-%s\n", path, n, lines[n])
+			case column:
+				fmt.Fprintf(w, "%s:%d:%d:%s\n", path, n, m.Column, m.Text)
+			default:
+				fmt.Fprintf(w, "%s:%d:%s\n", path, n, m.Text)
+			}
+		}
+		start = end
+	}
+}
+
+// nonNil returns s, or an empty slice if s is nil, so it encodes as [] in JSON.
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
+
+func runTrash(ctx context.Context, e *env, flags *flag.FlagSet, args []string) error {
+	list := flags.Bool("list", false, "list the items in the trash")
+	restore := flags.Bool("restore", false, "restore the items with the given names or original paths")
+	empty := flags.Bool("empty", false, "delete items from the trash for good")
+	var olderThan ageValue
+	flags.Var(&olderThan, "older-than", "with --empty, only delete items deleted longer than `age` ago, such as 30d")
+	var largerThan, maxSize fsutils.Size
+	flags.Var(&largerThan, "larger-than", "with --empty, only delete items bigger than `size`")
+	flags.Var(&maxSize, "max-size", "with --empty, delete the oldest items until the trash holds at most `size`")
+	dryRun := flags.Bool("dry-run", false, "with --empty, list what would be deleted")
+	conflict := registerConflictFlags(flags)
+	args, err := e.parse(flags, args, 0, -1)
+	if err != nil {
+		return err
+	}
+
+	actions := 0
+	for _, set := range []bool{*list, *restore, *empty} {
+		if set {
+			actions++
+		}
+	}
+	switch {
+	case actions > 1:
+		return usageError{"use only one of --list, --restore and --empty"}
+	case *list || *empty:
+		if len(args) > 0 {
+			return usageError{"too many arguments"}
+		}
+	case len(args) == 0:
+		return usageError{"missing arguments"}
+	}
+
+	switch {
+	case *list:
+		items, err := fsutils.ListTrash()
+		if err != nil {
+			return err
+		}
+		return e.printTrash(items)
+	case *empty:
+		opts := fsutils.EmptyTrashOptions{OlderThan: time.Duration(olderThan), LargerThan: largerThan, MaxSize: maxSize, DryRun: *dryRun}
+		items, err := fsutils.EmptyTrash(opts)
+		if err != nil {
+			return err
+		}
+		return e.printTrash(items)
+	case *restore:
+		opts, err := conflict.options()
+		if err != nil {
+			return err
+		}
+		items, err := fsutils.ListTrash()
+		if err != nil {
+			return err
+		}
+		restored := []string{}
+		for _, arg := range args {
+			item, ok := findTrashItem(items, arg)
+			if !ok {
+				return fmt.Errorf("'%s' is not in the trash", arg)
+			}
+			path, err := fsutils.RestoreFromTrash(item, opts)
+			if err != nil {
+				return err
+			}
+			restored = append(restored, path)
+		}
+		if e.json {
+			return e.printJSON(map[string][]string{"restored": restored})
+		}
+		return nil
+	}
+
+	items := []fsutils.TrashItem{}
+	for _, path := range args {
+		item, err := fsutils.MoveToTrash(path)
+		if err != nil {
+			return err
+		}
+		items = append(items, item)
+	}
+	if e.json {
+		return e.printJSON(items)
+	}
+	return nil
+}
+
+// findTrashItem returns the item called name in its trash can or, failing
+// that, the most recently deleted item from the path name.
+func findTrashItem(items []fsutils.TrashItem, name string) (fsutils.TrashItem, bool) {
+	for _, item := range items {
+		if item.Name == name {
+			return item, true
+		}
+	}
+	abs, err := filepath.Abs(name)
+	if err != nil {
+		return fsutils.TrashItem{}, false
+	}
+	for i := len(items) - 1; i >= 0; i-- {
+		if items[i].OriginalPath == abs {
+			return items[i], true
+		}
+	}
+	return fsutils.TrashItem{}, false
+}
@@ -0,0 +1,155 @@
+// Command fsutils exposes the fsutils package on the command line, so shell
+// scripts get the same semantics as Go code using the library.
+//
+// Usage:
+//
+//	fsutils <command> [flags] [arguments]
+//
+// The commands are cp, mv, rm, mkdir, ls, info, du, find, grep, tree, hash,
+// sync, diff and trash; run "fsutils help <command>" for the flags of each.
+// Every command accepts --json, which prints results to stdout as JSON and
+// errors to stderr as a JSON object.
+//
+// Exit status:
+//
+//	0   success
+//	1   diff found differences, or grep found no matches
+//	2   the operation failed
+//	3   some paths failed with --continue-on-error; the rest were processed
+//	64  invalid command line
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+
+	"github.com/utsav-56/go_fs_utils/fsutils"
+)
+
+// Exit codes returned by run.
+const (
+	exitOK      = 0
+	exitDiffer  = 1
+	exitError   = 2
+	exitPartial = 3
+	exitUsage   = 64
+)
+
+// errDiffer is returned by commands whose result is a negative answer
+// rather than a failure, such as diff finding differences or grep finding
+// no matches.
+var errDiffer = errors.New("differences found")
+
+// usageError reports an invalid command line. An empty message means the
+// flag package has already explained the problem.
+type usageError struct{ msg string }
+
+func (e usageError) Error() string { return e.msg }
+
+// command describes one subcommand.
+type command struct {
+	args    string // Synopsis of the positional arguments
+	summary string
+	run     func(ctx context.Context, e *env, flags *flag.FlagSet, args []string) error
+}
+
+var commands = map[string]command{
+	"cp":    {"[flags] SRC... DST", "Copy files and directory trees, into DST if it is a directory.", runCp},
+	"mv":    {"[flags] SRC... DST", "Move files and directories, copying across filesystems.", runMv},
+	"rm":    {"[flags] PATH...", "Remove files, or directory trees with -r.", runRm},
+	"mkdir": {"[flags] PATH...", "Create directories and their parents.", runMkdir},
+	"ls":    {"[flags] [DIR...]", "List directory entries.", runLs},
+	"info":  {"[flags] PATH...", "Show detailed information about paths.", runInfo},
+	"du":    {"[flags] PATH...", "Summarise disk usage of trees.", runDu},
+	"find":  {"[flags] ROOT...", "Find entries below roots.", runFind},
+	"grep":  {"[flags] PATTERN [PATH...]", "Search file contents for a regular expression.", runGrep},
+	"tree":  {"[flags] [DIR]", "Render a directory as a tree.", runTree},
+	"hash":  {"[flags] PATH...", "Print SHA-256 checksums of files and trees.", runHash},
+	"sync":  {"[flags] SRC DST", "Make DST mirror SRC, copying only what changed.", runSync},
+	"diff":  {"[flags] A B", "Compare two directory trees.", runDiff},
+	"trash": {"[flags] PATH... | --list | --restore NAME... | --empty", "Move paths to the trash, or list, restore and empty it.", runTrash},
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	code := run(ctx, os.Args[1:], os.Stdout, os.Stderr)
+	stop()
+	os.Exit(code)
+}
+
+// run executes the command line args and returns the exit status.
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		usage(stderr)
+		return exitUsage
+	}
+	name, args := args[0], args[1:]
+	switch name {
+	case "help", "-h", "-help", "--help":
+		if len(args) == 0 {
+			usage(stdout)
+			return exitOK
+		}
+		name, args = args[0], []string{"-h"}
+	}
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(stderr, "fsutils: unknown command %q\n", name)
+		usage(stderr)
+		return exitUsage
+	}
+
+	e := &env{name: name, stdout: stdout, stderr: stderr}
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: fsutils %s %s\n\n%s\n\nflags:\n", name, cmd.args, cmd.summary)
+		flags.PrintDefaults()
+	}
+	flags.BoolVar(&e.json, "json", false, "print results as JSON")
+
+	err := cmd.run(ctx, e, flags, args)
+	var multi *fsutils.MultiError
+	var invalid usageError
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.Is(err, flag.ErrHelp):
+		return exitOK
+	case errors.Is(err, errDiffer):
+		return exitDiffer
+	case errors.As(err, &invalid):
+		if invalid.msg != "" {
+			fmt.Fprintf(stderr, "fsutils %s: %s\n", name, invalid.msg)
+			flags.Usage()
+		}
+		return exitUsage
+	case errors.As(err, &multi):
+		e.reportError(err)
+		return exitPartial
+	default:
+		e.reportError(err)
+		return exitError
+	}
+}
+
+// usage prints the list of commands.
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: fsutils <command> [flags] [arguments]")
+	fmt.Fprintln(w, "\ncommands:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-6s %s\n", name, commands[name].summary)
+	}
+	fmt.Fprintln(w, "\nRun 'fsutils help <command>' for the flags of a command.")
+}
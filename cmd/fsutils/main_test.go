@@ -0,0 +1,197 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/utsav-56/go_fs_utils/fsutils"
+)
+
+// runCommand runs the command line args and returns its exit status and stdout.
+func runCommand(t *testing.T, args ...string) (int, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	code := run(context.Background(), args, &stdout, &stderr)
+	return code, stdout.String()
+}
+
+func TestCommands(t *testing.T) {
+	// Create a temporary directory for our tests
+	tempDir, err := os.MkdirTemp("", "fsutils-cmd-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	src := filepath.Join(tempDir, "src")
+	dst := filepath.Join(tempDir, "dst")
+	if code, _ := runCommand(t, "mkdir", filepath.Join(src, "sub")); code != exitOK {
+		t.Fatalf("mkdir exited with %d", code)
+	}
+	os.WriteFile(filepath.Join(src, "sub", "file.txt"), []byte("data"), 0644)
+
+	// Test cp reports its result as JSON
+	code, out := runCommand(t, "cp", "--json", src, dst)
+	if code != exitOK {
+		t.Fatalf("cp exited with %d", code)
+	}
+	var copied map[string]int
+	if err := json.Unmarshal([]byte(out), &copied); err != nil || copied["files"] != 1 {
+		t.Errorf("cp --json printed %q: %v", out, err)
+	}
+
+	// Test cp --exclude
+	excluded := filepath.Join(tempDir, "excluded")
+	if code, _ := runCommand(t, "cp", "--exclude", "*.txt", src, excluded); code != exitOK {
+		t.Errorf("cp --exclude exited with %d", code)
+	}
+	if _, err := os.Stat(filepath.Join(excluded, "sub", "file.txt")); !os.IsNotExist(err) {
+		t.Errorf("cp --exclude copied an excluded file")
+	}
+
+	// Test cp of several sources needs a directory target
+	into := filepath.Join(tempDir, "into")
+	if code, _ := runCommand(t, "cp", src, filepath.Join(src, "sub", "file.txt"), into); code != exitError {
+		t.Errorf("cp of several sources to a missing directory exited with %d", code)
+	}
+	os.Mkdir(into, 0755)
+	if code, _ := runCommand(t, "cp", src, filepath.Join(src, "sub", "file.txt"), into); code != exitOK {
+		t.Errorf("cp of several sources exited with %d", code)
+	}
+	if _, err := os.Stat(filepath.Join(into, "src", "sub", "file.txt")); err != nil {
+		t.Errorf("cp did not copy into the directory: %v", err)
+	}
+
+	// Test cp --backup keeps the overwritten file
+	backedUp := filepath.Join(into, "file.txt")
+	os.WriteFile(filepath.Join(tempDir, "new.txt"), []byte("new"), 0644)
+	if code, _ := runCommand(t, "cp", "--backup", "numbered", filepath.Join(tempDir, "new.txt"), backedUp); code != exitOK {
+		t.Errorf("cp --backup exited with %d", code)
+	}
+	if data, err := os.ReadFile(backedUp + ".~1~"); err != nil || string(data) != "data" {
+		t.Errorf("cp --backup did not keep the old file: %q, %v", data, err)
+	}
+	if code, _ := runCommand(t, "cp", "--backup", "sometimes", filepath.Join(tempDir, "new.txt"), backedUp); code != exitUsage {
+		t.Errorf("cp with an unknown backup mode exited with %d", code)
+	}
+
+	// Test cp --on-conflict rename keeps the existing file
+	if code, _ := runCommand(t, "cp", "--on-conflict", "rename", "--name-template", "{name}-{n}{ext}", filepath.Join(tempDir, "new.txt"), backedUp); code != exitOK {
+		t.Errorf("cp --on-conflict rename exited with %d", code)
+	}
+	if data, err := os.ReadFile(filepath.Join(into, "file-1.txt")); err != nil || string(data) != "new" {
+		t.Errorf("cp --on-conflict rename did not write the next free name: %q, %v", data, err)
+	}
+	if code, _ := runCommand(t, "cp", "--on-conflict", "rename", "--name-template", "copy", filepath.Join(tempDir, "new.txt"), backedUp); code != exitUsage {
+		t.Errorf("cp with a template without {n} exited with %d", code)
+	}
+
+	// Test diff exit codes
+	if code, _ := runCommand(t, "diff", "--checksum", src, dst); code != exitOK {
+		t.Errorf("diff of identical trees exited with %d", code)
+	}
+	os.WriteFile(filepath.Join(dst, "extra.txt"), nil, 0644)
+	if code, out := runCommand(t, "diff", "--checksum", src, dst); code != exitDiffer || out != "A extra.txt\n" {
+		t.Errorf("diff exited with %d and printed %q", code, out)
+	}
+
+	// Test find
+	if code, out := runCommand(t, "find", "--type", "f", src); code != exitOK || out != filepath.Join(src, "sub", "file.txt")+"\n" {
+		t.Errorf("find exited with %d and printed %q", code, out)
+	}
+	if code, out := runCommand(t, "find", "--where", "name:*.txt and depth>1", src); code != exitOK || out != filepath.Join(src, "sub", "file.txt")+"\n" {
+		t.Errorf("find --where exited with %d and printed %q", code, out)
+	}
+	if code, _ := runCommand(t, "find", "--where", "size>", src); code != exitUsage {
+		t.Errorf("find with an invalid --where exited with %d", code)
+	}
+
+	// Test grep
+	if code, out := runCommand(t, "grep", "-n", src); code != exitUsage {
+		t.Errorf("grep with an unknown flag exited with %d and printed %q", code, out)
+	}
+	file := filepath.Join(src, "sub", "file.txt")
+	os.WriteFile(file, []byte("alpha\nbeta\ngamma\n"), 0644)
+	if code, out := runCommand(t, "grep", "-B", "1", "--column", "m+a$", src); code != exitOK || out != file+"-2-beta\n"+file+":3:3:gamma\n" {
+		t.Errorf("grep exited with %d and printed %q", code, out)
+	}
+	if code, _ := runCommand(t, "grep", "delta", src); code != exitDiffer {
+		t.Errorf("grep without matches exited with %d", code)
+	}
+	if code, _ := runCommand(t, "grep", "(", src); code != exitUsage {
+		t.Errorf("grep with an invalid pattern exited with %d", code)
+	}
+
+	// Test hash and grep carry on past a root with failures
+	broken := []string{filepath.Join(tempDir, "broken1"), filepath.Join(tempDir, "broken2")}
+	for _, root := range broken {
+		os.MkdirAll(filepath.Join(root, "sub", ".ignore"), 0755)
+		os.WriteFile(filepath.Join(root, "ok.txt"), []byte("alpha\n"), 0644)
+	}
+	for _, cmd := range []string{"hash", "grep"} {
+		args := []string{cmd, "--continue-on-error", "--ignore-file", ".ignore", "--json"}
+		if cmd == "grep" {
+			args = append(args, "alpha")
+		}
+		var stdout, stderr bytes.Buffer
+		code := run(context.Background(), append(args, broken...), &stdout, &stderr)
+		var failures struct {
+			Failures []json.RawMessage `json:"failures"`
+		}
+		json.Unmarshal(stderr.Bytes(), &failures)
+		if code != exitPartial || len(failures.Failures) != 2 || bytes.Count(stdout.Bytes(), []byte("ok.txt")) != 2 {
+			t.Errorf("%s over two failing roots exited with %d, printed %q and reported %q", cmd, code, stdout.String(), stderr.String())
+		}
+	}
+
+	// Test the trash
+	if runtime.GOOS == "linux" {
+		t.Setenv("XDG_DATA_HOME", filepath.Join(tempDir, "data"))
+		trashed := filepath.Join(tempDir, "trashed.txt")
+		os.WriteFile(trashed, []byte("bye"), 0644)
+		if code, _ := runCommand(t, "rm", "--trash", trashed); code != exitOK || fsutils.FileExists(trashed) {
+			t.Errorf("rm --trash exited with %d", code)
+		}
+		var items []fsutils.TrashItem
+		code, out := runCommand(t, "trash", "--empty", "--dry-run", "--older-than", "1000w", "--json")
+		if err := json.Unmarshal([]byte(out), &items); code != exitOK || err != nil || len(items) != 0 {
+			t.Errorf("trash --empty --dry-run exited with %d and printed %q", code, out)
+		}
+		if code, _ := runCommand(t, "trash", "--restore", trashed); code != exitOK || !fsutils.FileExists(trashed) {
+			t.Errorf("trash --restore exited with %d", code)
+		}
+		if code, _ := runCommand(t, "trash", "--list", "--restore"); code != exitUsage {
+			t.Errorf("trash with two actions exited with %d", code)
+		}
+	}
+
+	// Test rm --dry-run and the guard rails
+	if code, out := runCommand(t, "rm", "-r", "--dry-run", src); code != exitOK || out != filepath.Join(src, "sub", "file.txt")+"\n"+filepath.Join(src, "sub")+"\n"+src+"\n" {
+		t.Errorf("rm --dry-run exited with %d and printed %q", code, out)
+	}
+	if !fsutils.DirExists(src) {
+		t.Errorf("rm --dry-run removed the directory")
+	}
+	if code, _ := runCommand(t, "rm", "-r", "."); code != exitError {
+		t.Errorf("rm of the current directory exited with %d", code)
+	}
+	if code, _ := runCommand(t, "rm", "-r", "--allowed-root", dst, src); code != exitError || !fsutils.DirExists(src) {
+		t.Errorf("rm outside the allowed roots exited with %d", code)
+	}
+
+	// Test errors and usage
+	if code, _ := runCommand(t, "rm", src); code != exitError {
+		t.Errorf("rm of a directory without -r exited with %d", code)
+	}
+	if code, _ := runCommand(t, "cp", src); code != exitUsage {
+		t.Errorf("cp with one argument exited with %d", code)
+	}
+	if code, _ := runCommand(t, "nope"); code != exitUsage {
+		t.Errorf("unknown command exited with %d", code)
+	}
+}
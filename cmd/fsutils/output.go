@@ -0,0 +1,277 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/utsav-56/go_fs_utils/fsutils"
+)
+
+// env carries the state shared by a command's implementation.
+type env struct {
+	name   string // Command being run
+	stdout io.Writer
+	stderr io.Writer
+	json   bool // --json was given
+}
+
+// parse parses the flags in args and returns the positional arguments,
+// checking there are at least min and, unless max is negative, at most max.
+func (e *env) parse(flags *flag.FlagSet, args []string, min, max int) ([]string, error) {
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return nil, err
+		}
+		return nil, usageError{}
+	}
+	rest := flags.Args()
+	switch {
+	case len(rest) < min:
+		return nil, usageError{"missing arguments"}
+	case max >= 0 && len(rest) > max:
+		return nil, usageError{"too many arguments"}
+	}
+	return rest, nil
+}
+
+// printJSON writes v to stdout as indented JSON.
+func (e *env) printJSON(v interface{}) error {
+	enc := json.NewEncoder(e.stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// jsonError is the shape of errors printed with --json.
+type jsonError struct {
+	Command   string        `json:"command"`
+	Error     string        `json:"error"`
+	Failures  []jsonFailure `json:"failures,omitempty"`
+	Succeeded int           `json:"succeeded,omitempty"`
+}
+
+// jsonFailure is one failed path of a *fsutils.MultiError.
+type jsonFailure struct {
+	Op    string `json:"op"`
+	Path  string `json:"path"`
+	Error string `json:"error"`
+}
+
+// mergeFailures adds the failures of multi to partial, which may be nil, so
+// a command run over several roots reports them all together.
+func mergeFailures(partial, multi *fsutils.MultiError) *fsutils.MultiError {
+	if partial == nil {
+		partial = &fsutils.MultiError{}
+	}
+	partial.Errors = append(partial.Errors, multi.Errors...)
+	partial.Succeeded += multi.Succeeded
+	return partial
+}
+
+// reportError writes err to stderr, listing every failure of a
+// *fsutils.MultiError, as a JSON object with --json.
+func (e *env) reportError(err error) {
+	var multi *fsutils.MultiError
+	errors.As(err, &multi)
+	if !e.json {
+		if multi == nil {
+			fmt.Fprintf(e.stderr, "fsutils %s: %v\n", e.name, err)
+			return
+		}
+		for _, failure := range multi.Errors {
+			fmt.Fprintf(e.stderr, "fsutils %s: %v\n", e.name, failure)
+		}
+		fmt.Fprintf(e.stderr, "fsutils %s: %d failed, %d succeeded\n", e.name, len(multi.Errors), multi.Succeeded)
+		return
+	}
+	report := jsonError{Command: e.name, Error: err.Error()}
+	if multi != nil {
+		report.Succeeded = multi.Succeeded
+		for _, failure := range multi.Errors {
+			report.Failures = append(report.Failures, jsonFailure{failure.Op, failure.Path, failure.Err.Error()})
+		}
+	}
+	enc := json.NewEncoder(e.stderr)
+	enc.Encode(report)
+}
+
+// progress returns a callback that shows progress on stderr, at most ten
+// times a second, and a function that ends the progress line. Both are
+// no-ops unless show is set.
+func (e *env) progress(show bool) (fsutils.ProgressFunc, func()) {
+	if !show {
+		return nil, func() {}
+	}
+	var last time.Time
+	shown := false
+	fn := func(p fsutils.Progress) {
+		if now := time.Now(); now.Sub(last) >= 100*time.Millisecond {
+			last = now
+			shown = true
+			fmt.Fprintf(e.stderr, "\r%s", formatProgress(p))
+		}
+	}
+	return fn, func() {
+		if shown {
+			fmt.Fprintln(e.stderr)
+		}
+	}
+}
+
+// formatProgress renders a progress update as a single line.
+func formatProgress(p fsutils.Progress) string {
+	files := fmt.Sprint(p.FilesDone)
+	if p.FilesTotal > 0 {
+		files += fmt.Sprintf("/%d", p.FilesTotal)
+	}
+	bytes := fmt.Sprint(p.BytesDone)
+	if p.BytesTotal > 0 {
+		bytes += fmt.Sprintf("/%d", p.BytesTotal)
+	}
+	return fmt.Sprintf("%s files, %s bytes", files, bytes)
+}
+
+// formatFlag registers the --format flag of a listing command.
+func formatFlag(flags *flag.FlagSet) *string {
+	return flags.String("format", "", "print records as `format`: table, json, ndjson or csv")
+}
+
+// recordWriter returns a writer for the records of a listing command, or
+// nil if neither --format nor --json was given. --json means --format json.
+func (e *env) recordWriter(format string) (*fsutils.RecordWriter, error) {
+	if format == "" && e.json {
+		format = "json"
+	}
+	if format == "" {
+		return nil, nil
+	}
+	f, err := fsutils.ParseFormat(format)
+	if err != nil {
+		return nil, usageError{err.Error()}
+	}
+	return fsutils.NewRecordWriter(e.stdout, f), nil
+}
+
+// patternList is a flag.Value collecting the values of a repeated flag.
+type patternList []string
+
+func (l *patternList) String() string { return strings.Join(*l, ",") }
+
+func (l *patternList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// ignoreOptions holds the --exclude and --ignore-file flags of a command
+// that walks trees.
+type ignoreOptions struct {
+	patterns patternList
+	file     string
+}
+
+// ignoreFlags registers the --exclude and --ignore-file flags.
+func ignoreFlags(flags *flag.FlagSet) *ignoreOptions {
+	o := &ignoreOptions{}
+	flags.Var(&o.patterns, "exclude", "leave out paths matching the gitignore-style `pattern` (repeatable)")
+	flags.StringVar(&o.file, "ignore-file", "", "apply the gitignore-style patterns of files with this `name`, such as "+fsutils.DefaultIgnoreFile)
+	return o
+}
+
+// matcher returns a matcher for the --exclude patterns, or nil if there are none.
+func (o *ignoreOptions) matcher() *fsutils.IgnoreMatcher {
+	if len(o.patterns) == 0 {
+		return nil
+	}
+	return fsutils.NewIgnoreMatcher(o.patterns...)
+}
+
+// backupFlags holds the backup flags of a command that overwrites files.
+type backupFlags struct {
+	mode   string
+	suffix string
+	dir    string
+	keep   int
+}
+
+// registerBackupFlags registers --backup, --suffix, --backup-dir and --keep-backups.
+func registerBackupFlags(flags *flag.FlagSet) *backupFlags {
+	b := &backupFlags{}
+	flags.StringVar(&b.mode, "backup", "none", "keep overwritten files: none, simple, numbered, existing or timestamped")
+	flags.StringVar(&b.suffix, "suffix", "~", "`suffix` of simple backups")
+	flags.StringVar(&b.dir, "backup-dir", "", "put backups in `dir`, relative to the overwritten file's directory")
+	flags.IntVar(&b.keep, "keep-backups", 0, "keep at most `n` numbered or timestamped backups of a file (0: all)")
+	return b
+}
+
+// options returns the BackupOptions selected by the flags.
+func (b *backupFlags) options() (fsutils.BackupOptions, error) {
+	mode, err := fsutils.ParseBackupMode(b.mode)
+	if err != nil {
+		return fsutils.BackupOptions{}, usageError{err.Error()}
+	}
+	return fsutils.BackupOptions{Mode: mode, Suffix: b.suffix, Dir: b.dir, Keep: b.keep}, nil
+}
+
+// conflictFlags holds the flags choosing what happens to existing destinations.
+type conflictFlags struct {
+	policy   string
+	template string
+}
+
+// registerConflictFlags registers --on-conflict and --name-template.
+func registerConflictFlags(flags *flag.FlagSet) *conflictFlags {
+	c := &conflictFlags{}
+	flags.StringVar(&c.policy, "on-conflict", "overwrite", "what to do with existing files: overwrite or rename")
+	flags.StringVar(&c.template, "name-template", fsutils.TemplateParens, "`template` of the names tried by --on-conflict rename, with {name}, {ext}, {n} and {time}")
+	return c
+}
+
+// options returns the ConflictOptions selected by the flags.
+func (c *conflictFlags) options() (fsutils.ConflictOptions, error) {
+	opts := fsutils.ConflictOptions{Template: c.template}
+	switch c.policy {
+	case "overwrite":
+		opts.Policy = fsutils.ConflictOverwrite
+	case "rename":
+		opts.Policy = fsutils.ConflictRename
+	default:
+		return opts, usageError{fmt.Sprintf("invalid --on-conflict %q", c.policy)}
+	}
+	if !strings.Contains(c.template, "{n}") && !strings.Contains(c.template, "{time}") {
+		return opts, usageError{fmt.Sprintf("--name-template %q has neither {n} nor {time}", c.template)}
+	}
+	return opts, nil
+}
+
+// ageValue is a flag.Value holding a duration parsed with fsutils.ParseAge.
+type ageValue time.Duration
+
+func (a *ageValue) String() string { return time.Duration(*a).String() }
+
+func (a *ageValue) Set(value string) error {
+	d, err := fsutils.ParseAge(value)
+	if err != nil {
+		return err
+	}
+	*a = ageValue(d)
+	return nil
+}
+
+// printTrash prints trash items, one per line with their deletion date,
+// size and original path, or as JSON.
+func (e *env) printTrash(items []fsutils.TrashItem) error {
+	if e.json {
+		if items == nil {
+			items = []fsutils.TrashItem{}
+		}
+		return e.printJSON(items)
+	}
+	for _, item := range items {
+		fmt.Fprintf(e.stdout, "%s\t%s\t%s\t%s\n", item.DeletionDate.Format("2006-01-02 15:04:05"), fsutils.Size(item.Size), item.Name, item.OriginalPath)
+	}
+	return nil
+}
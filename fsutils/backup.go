@@ -0,0 +1,232 @@
+// Package fsutils provides filesystem utility functions for Go applications.
+package fsutils
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BackupMode selects how a file about to be overwritten is kept, like the
+// --backup option of cp(1) and mv(1).
+type BackupMode int
+
+const (
+	// BackupNone overwrites files without keeping them.
+	BackupNone BackupMode = iota
+	// BackupSimple renames the file with a suffix, "~" by default, replacing
+	// any earlier simple backup.
+	BackupSimple
+	// BackupNumbered renames the file to "name.~N~", one more than the
+	// highest existing number.
+	BackupNumbered
+	// BackupExisting makes numbered backups of files that already have one,
+	// and simple backups of the others.
+	BackupExisting
+	// BackupTimestamped renames the file to "name.~20060102T150405.000Z~",
+	// with the UTC time of the backup.
+	BackupTimestamped
+)
+
+// String returns the name of the mode as accepted by ParseBackupMode.
+func (m BackupMode) String() string {
+	switch m {
+	case BackupSimple:
+		return "simple"
+	case BackupNumbered:
+		return "numbered"
+	case BackupExisting:
+		return "existing"
+	case BackupTimestamped:
+		return "timestamped"
+	default:
+		return "none"
+	}
+}
+
+// ParseBackupMode returns the mode called name: "none", "simple",
+// "numbered", "existing" or "timestamped". The names of the --backup option
+// of cp(1), "off", "never", "t" and "nil", are accepted as well.
+func ParseBackupMode(name string) (BackupMode, error) {
+	aliases := map[string]BackupMode{"off": BackupNone, "never": BackupSimple, "t": BackupNumbered, "nil": BackupExisting}
+	if m, ok := aliases[strings.ToLower(name)]; ok {
+		return m, nil
+	}
+	for _, m := range []BackupMode{BackupNone, BackupSimple, BackupNumbered, BackupExisting, BackupTimestamped} {
+		if strings.EqualFold(name, m.String()) {
+			return m, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown backup mode %q", name)
+}
+
+// backupTimeLayout is the time format of timestamped backups. Its names
+// sort in chronological order.
+const backupTimeLayout = "20060102T150405.000Z"
+
+// BackupOptions controls whether and how the copy and move functions that
+// take CopyOptions keep the files they overwrite.
+type BackupOptions struct {
+	// Mode selects the naming scheme; BackupNone disables backups.
+	Mode BackupMode
+	// Suffix is appended by simple backups. Defaults to "~".
+	Suffix string
+	// Dir, if set, receives the backups instead of the directory of the
+	// file. A relative Dir is taken relative to the directory of the file.
+	Dir string
+	// Keep, if positive, is how many numbered or timestamped backups are
+	// kept of each file; the oldest are removed once it is exceeded.
+	Keep int
+}
+
+// BackupFile moves the file at path out of the way according to opts, as a
+// copy or move about to overwrite it would, and returns the path of the
+// backup. It returns "" if no backup was needed because opts.Mode is
+// BackupNone, or path does not exist or is a directory.
+func BackupFile(path string, opts BackupOptions) (string, error) {
+	if opts.Mode == BackupNone {
+		return "", nil
+	}
+	info, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return "", nil
+	}
+
+	dir := filepath.Dir(path)
+	if opts.Dir != "" {
+		dir = opts.Dir
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(filepath.Dir(path), dir)
+		}
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return "", err
+		}
+	}
+	name := filepath.Base(path)
+	existing, err := listBackups(dir, name)
+	if err != nil {
+		return "", err
+	}
+
+	mode := opts.Mode
+	if mode == BackupExisting {
+		mode = BackupSimple
+		if len(existing.numbered) > 0 {
+			mode = BackupNumbered
+		}
+	}
+	var backup string
+	switch mode {
+	case BackupNumbered:
+		next := 1
+		if n := len(existing.numbered); n > 0 {
+			next = existing.numbered[n-1] + 1
+		}
+		backup = filepath.Join(dir, name+".~"+strconv.Itoa(next)+"~")
+	case BackupTimestamped:
+		now := time.Now().UTC()
+		for n := 0; ; n++ {
+			if n == maxUniqueAttempts {
+				return "", &os.PathError{Op: "backup", Path: path, Err: fmt.Errorf("no free name after %d attempts", maxUniqueAttempts)}
+			}
+			backup = filepath.Join(dir, name+".~"+now.Add(time.Duration(n)*time.Millisecond).Format(backupTimeLayout)+"~")
+			_, err := os.Lstat(backup)
+			if os.IsNotExist(err) {
+				break
+			}
+			if err != nil {
+				return "", err
+			}
+		}
+	default:
+		suffix := opts.Suffix
+		if suffix == "" {
+			suffix = "~"
+		}
+		backup = filepath.Join(dir, name+suffix)
+	}
+
+	if err := MoveContext(context.Background(), path, backup, CopyOptions{}); err != nil {
+		return "", err
+	}
+	if opts.Keep > 0 {
+		if err := pruneBackups(dir, name, mode, opts.Keep); err != nil {
+			return backup, err
+		}
+	}
+	return backup, nil
+}
+
+// restoreBackup moves a backup made by BackupFile back to path after the
+// operation that overwrote path failed. It does nothing if backup is "".
+func restoreBackup(backup, path string) {
+	if backup != "" {
+		MoveContext(context.Background(), backup, path, CopyOptions{})
+	}
+}
+
+// backupSet lists the backups of one file.
+type backupSet struct {
+	numbered    []int    // Numbers of the numbered backups, ascending
+	timestamped []string // Names of the timestamped backups, oldest first
+}
+
+// listBackups finds the numbered and timestamped backups of name in dir.
+func listBackups(dir, name string) (backupSet, error) {
+	var set backupSet
+	entries, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return set, err
+	}
+	for _, entry := range entries {
+		tag := strings.TrimPrefix(entry.Name(), name+".~")
+		if tag == entry.Name() || !strings.HasSuffix(tag, "~") {
+			continue
+		}
+		tag = strings.TrimSuffix(tag, "~")
+		if n, err := strconv.Atoi(tag); err == nil && n > 0 {
+			set.numbered = append(set.numbered, n)
+		} else if _, err := time.Parse(backupTimeLayout, tag); err == nil {
+			set.timestamped = append(set.timestamped, entry.Name())
+		}
+	}
+	sort.Ints(set.numbered)
+	sort.Strings(set.timestamped)
+	return set, nil
+}
+
+// pruneBackups removes the oldest backups of name in dir made with mode
+// until at most keep are left.
+func pruneBackups(dir, name string, mode BackupMode, keep int) error {
+	set, err := listBackups(dir, name)
+	if err != nil {
+		return err
+	}
+	var names []string
+	switch mode {
+	case BackupNumbered:
+		for _, n := range set.numbered {
+			names = append(names, name+".~"+strconv.Itoa(n)+"~")
+		}
+	case BackupTimestamped:
+		names = set.timestamped
+	}
+	for len(names) > keep {
+		if err := os.Remove(filepath.Join(dir, names[0])); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		names = names[1:]
+	}
+	return nil
+}
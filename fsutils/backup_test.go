@@ -0,0 +1,169 @@
+package fsutils_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/utsav-56/go_fs_utils/fsutils"
+)
+
+func TestParseBackupMode(t *testing.T) {
+	tests := map[string]fsutils.BackupMode{
+		"none":        fsutils.BackupNone,
+		"off":         fsutils.BackupNone,
+		"simple":      fsutils.BackupSimple,
+		"never":       fsutils.BackupSimple,
+		"numbered":    fsutils.BackupNumbered,
+		"t":           fsutils.BackupNumbered,
+		"existing":    fsutils.BackupExisting,
+		"nil":         fsutils.BackupExisting,
+		"Timestamped": fsutils.BackupTimestamped,
+	}
+	for name, want := range tests {
+		if got, err := fsutils.ParseBackupMode(name); err != nil || got != want {
+			t.Errorf("ParseBackupMode(%q) = %v, %v; want %v", name, got, err, want)
+		}
+	}
+	if _, err := fsutils.ParseBackupMode("sometimes"); err == nil {
+		t.Errorf("ParseBackupMode of an unknown mode succeeded")
+	}
+}
+
+func TestBackupFile(t *testing.T) {
+	// Create a temporary directory for our tests
+	tempDir, err := os.MkdirTemp("", "fsutils-backup-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	file := filepath.Join(tempDir, "file.txt")
+	write := func(content string) {
+		if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+	read := func(path string) string {
+		data, _ := os.ReadFile(path)
+		return string(data)
+	}
+
+	// Test nothing happens for a missing file or with BackupNone
+	if backup, err := fsutils.BackupFile(file, fsutils.BackupOptions{Mode: fsutils.BackupSimple}); err != nil || backup != "" {
+		t.Errorf("BackupFile of a missing file returned %q, %v", backup, err)
+	}
+	write("v1")
+	if backup, err := fsutils.BackupFile(file, fsutils.BackupOptions{}); err != nil || backup != "" || !fsutils.FileExists(file) {
+		t.Errorf("BackupFile with BackupNone returned %q, %v", backup, err)
+	}
+
+	// Test simple backups with a custom suffix
+	backup, err := fsutils.BackupFile(file, fsutils.BackupOptions{Mode: fsutils.BackupSimple, Suffix: ".bak"})
+	if err != nil || backup != file+".bak" || read(backup) != "v1" || fsutils.FileExists(file) {
+		t.Errorf("simple BackupFile returned %q, %v", backup, err)
+	}
+
+	// Test existing makes simple backups until a numbered one exists
+	write("v2")
+	if backup, err := fsutils.BackupFile(file, fsutils.BackupOptions{Mode: fsutils.BackupExisting}); err != nil || backup != file+"~" {
+		t.Errorf("existing BackupFile without numbered backups returned %q, %v", backup, err)
+	}
+
+	// Test numbered backups count up, and existing follows them
+	for i, want := range []string{".~1~", ".~2~"} {
+		write("n" + want)
+		backup, err := fsutils.BackupFile(file, fsutils.BackupOptions{Mode: fsutils.BackupNumbered})
+		if err != nil || backup != file+want {
+			t.Errorf("numbered BackupFile %d returned %q, %v", i, backup, err)
+		}
+	}
+	write("v3")
+	if backup, err := fsutils.BackupFile(file, fsutils.BackupOptions{Mode: fsutils.BackupExisting}); err != nil || backup != file+".~3~" {
+		t.Errorf("existing BackupFile with numbered backups returned %q, %v", backup, err)
+	}
+
+	// Test Keep removes the oldest numbered backups
+	write("v4")
+	if _, err := fsutils.BackupFile(file, fsutils.BackupOptions{Mode: fsutils.BackupNumbered, Keep: 2}); err != nil {
+		t.Fatalf("numbered BackupFile with Keep failed: %v", err)
+	}
+	if fsutils.FileExists(file+".~1~") || fsutils.FileExists(file+".~2~") || read(file+".~4~") != "v4" {
+		t.Errorf("numbered BackupFile with Keep did not prune the oldest backups")
+	}
+
+	// Test timestamped backups into a relative Dir, with Keep
+	opts := fsutils.BackupOptions{Mode: fsutils.BackupTimestamped, Dir: "backups", Keep: 2}
+	var backups []string
+	for i := 0; i < 3; i++ {
+		write("t" + string(rune('0'+i)))
+		backup, err := fsutils.BackupFile(file, opts)
+		if err != nil {
+			t.Fatalf("timestamped BackupFile failed: %v", err)
+		}
+		if filepath.Dir(backup) != filepath.Join(tempDir, "backups") || !strings.HasPrefix(filepath.Base(backup), "file.txt.~") {
+			t.Errorf("timestamped BackupFile returned %q", backup)
+		}
+		backups = append(backups, backup)
+	}
+	if backups[0] == backups[1] || fsutils.FileExists(backups[0]) || read(backups[2]) != "t2" {
+		t.Errorf("timestamped BackupFile did not keep the newest distinct backups: %v", backups)
+	}
+}
+
+func TestCopyAndMoveBackups(t *testing.T) {
+	// Create a temporary directory for our tests
+	tempDir, err := os.MkdirTemp("", "fsutils-backup-copy-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	src := filepath.Join(tempDir, "src.txt")
+	dst := filepath.Join(tempDir, "dst.txt")
+	os.WriteFile(src, []byte("new"), 0644)
+	os.WriteFile(dst, []byte("old"), 0644)
+	opts := fsutils.CopyOptions{Backup: fsutils.BackupOptions{Mode: fsutils.BackupSimple}}
+
+	// Test a copy backs up the file it overwrites
+	if _, err := fsutils.CopyFileWithOptions(src, dst, opts); err != nil {
+		t.Fatalf("CopyFileWithOptions failed: %v", err)
+	}
+	if data, _ := os.ReadFile(dst + "~"); string(data) != "old" {
+		t.Errorf("copy backup contains %q, want %q", data, "old")
+	}
+
+	// Test a move backs up the file it replaces
+	os.WriteFile(src, []byte("newer"), 0644)
+	if err := fsutils.MoveContext(context.Background(), src, dst, opts); err != nil {
+		t.Fatalf("MoveContext failed: %v", err)
+	}
+	if data, _ := os.ReadFile(dst + "~"); string(data) != "new" {
+		t.Errorf("move backup contains %q, want %q", data, "new")
+	}
+	if data, _ := os.ReadFile(dst); string(data) != "newer" {
+		t.Errorf("moved file contains %q, want %q", data, "newer")
+	}
+
+	// Test a move that cannot happen leaves dst alone
+	os.Remove(dst + "~")
+	if err := fsutils.MoveContext(context.Background(), filepath.Join(tempDir, "missing"), dst, opts); !os.IsNotExist(err) {
+		t.Errorf("MoveContext of a missing file returned %v", err)
+	}
+	if data, _ := os.ReadFile(dst); string(data) != "newer" || fsutils.FileExists(dst+"~") {
+		t.Errorf("failed MoveContext backed up dst and left %q", data)
+	}
+
+	// Test a backup name that cannot exist fails instead of looping
+	long := filepath.Join(tempDir, strings.Repeat("x", 240))
+	os.WriteFile(long, nil, 0644)
+	timestamped := fsutils.BackupOptions{Mode: fsutils.BackupTimestamped}
+	if _, err := fsutils.BackupFile(long, timestamped); err == nil {
+		t.Errorf("BackupFile with a name too long for a backup succeeded")
+	}
+	if !fsutils.FileExists(long) {
+		t.Errorf("failed BackupFile moved the file")
+	}
+}
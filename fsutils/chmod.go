@@ -0,0 +1,59 @@
+// Package fsutils provides filesystem utility functions for Go applications.
+package fsutils
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// ChmodOptions controls the behaviour of ChmodTree.
+type ChmodOptions struct {
+	// DirMode, if non-zero, is given to directories in place of the mode
+	// passed to ChmodTree, which then applies to files only.
+	DirMode os.FileMode
+	// Progress, if set, is called after every changed entry.
+	Progress ProgressFunc
+	// ContinueOnError makes ChmodTree skip paths whose mode cannot be changed
+	// and carry on. The failures are returned together as a *MultiError.
+	ContinueOnError bool
+}
+
+// ChmodTree sets the permission bits of root and everything below it to mode,
+// like chmod -R. A directory is changed before its entries are listed, so a
+// mode that makes it readable lets the walk continue into it. Symlinks are
+// left alone. It stops with ctx.Err() once ctx is cancelled.
+func ChmodTree(ctx context.Context, root string, mode os.FileMode, opts ChmodOptions) error {
+	t := newTracker(ctx, opts.Progress)
+	errs := newErrorList("chmod", opts.ContinueOnError)
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errs.record(path, err) == errSkipped || (errs.skipVanished(path, err) && path != root) {
+				return nil
+			}
+			return err
+		}
+		if d.Type()&fs.ModeSymlink != 0 {
+			return nil
+		}
+		if err := t.start(path); err != nil {
+			return err
+		}
+		perm := mode
+		if d.IsDir() && opts.DirMode != 0 {
+			perm = opts.DirMode
+		}
+		if err := os.Chmod(path, perm.Perm()); err != nil {
+			if err = errs.record(path, err); err == errSkipped || errs.skipVanished(path, err) {
+				return nil
+			}
+			return err
+		}
+		return t.addFile()
+	})
+	if err != nil {
+		return err
+	}
+	return errs.err(t.progress.FilesDone)
+}
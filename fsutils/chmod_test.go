@@ -0,0 +1,60 @@
+package fsutils_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/utsav-56/go_fs_utils/fsutils"
+)
+
+func TestChmodTree(t *testing.T) {
+	// Create a temporary directory for our tests
+	tempDir, err := os.MkdirTemp("", "fsutils-chmod-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	root := filepath.Join(tempDir, "root")
+	fsutils.Mkdir(filepath.Join(root, "sub"))
+	os.WriteFile(filepath.Join(root, "a.txt"), []byte("a"), 0644)
+	os.WriteFile(filepath.Join(root, "sub", "b.txt"), []byte("b"), 0644)
+	os.Symlink("a.txt", filepath.Join(root, "link"))
+	ctx := context.Background()
+
+	mode := func(rel string) os.FileMode {
+		info, err := os.Lstat(filepath.Join(root, filepath.FromSlash(rel)))
+		if err != nil {
+			t.Fatalf("Failed to stat %s: %v", rel, err)
+		}
+		return info.Mode().Perm()
+	}
+
+	// Test files and directories get their own modes
+	if err := fsutils.ChmodTree(ctx, root, 0600, fsutils.ChmodOptions{DirMode: 0700}); err != nil {
+		t.Fatalf("ChmodTree failed: %v", err)
+	}
+	for rel, want := range map[string]os.FileMode{".": 0700, "sub": 0700, "a.txt": 0600, "sub/b.txt": 0600} {
+		if got := mode(rel); got != want {
+			t.Errorf("ChmodTree left %s at %v, want %v", rel, got, want)
+		}
+	}
+
+	// Test a missing root fails
+	if err := fsutils.ChmodTree(ctx, filepath.Join(tempDir, "missing"), 0644, fsutils.ChmodOptions{ContinueOnError: true}); !os.IsNotExist(err) {
+		t.Errorf("ChmodTree of a missing root returned %v", err)
+	}
+
+	// Test a directory made unreadable is reported with ContinueOnError
+	if os.Geteuid() != 0 {
+		err := fsutils.ChmodTree(ctx, root, 0600, fsutils.ChmodOptions{DirMode: 0300, ContinueOnError: true})
+		var multi *fsutils.MultiError
+		if !errors.As(err, &multi) || len(multi.Errors) != 1 || multi.Errors[0].Op != "chmod" {
+			t.Errorf("ChmodTree of an unreadable tree returned %v", err)
+		}
+		os.Chmod(root, 0700)
+	}
+}
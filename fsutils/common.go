@@ -2,15 +2,227 @@
 package fsutils
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"io/fs"
 	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
 	"time"
 )
 
-// Mv moves a file or directory from src to dst.
-// This is a simple wrapper around os.Rename.
+// ErrUnsupported is returned by operations that are not available on the
+// current platform or filesystem.
+var ErrUnsupported = errors.New("operation not supported on this platform")
+
+// Mv moves a file or directory from src to dst, like mv(1): if dst is an
+// existing directory, src is moved into it. Like Cp, it expands a src
+// pattern with Glob and moves every match below dst. See MovePaths.
 func Mv(src, dst string) error {
-	return os.Rename(src, dst)
+	return MovePaths(context.Background(), []string{src}, dst, CopyOptions{})
+}
+
+// CopyPaths copies each of srcs to dst with the destination rules of cp(1):
+//
+//   - if dst is an existing directory, or ends in a path separator, every
+//     source is copied into it under its base name, creating dst if needed;
+//   - otherwise there must be a single source, which is copied to dst;
+//   - a directory source ending in a separator, such as "src/", stands for
+//     its contents, which are copied into the target as rsync does;
+//   - a source that does not exist but contains pattern syntax is expanded
+//     with Glob and its matches are copied below dst, as Cp does.
+//
+// Directories are copied with CopyDirContext and files with CopyFileContext,
+// using opts. Copying a directory into itself or a file onto itself fails.
+// With opts.ContinueOnError, failed sources are skipped and reported
+// together as a *MultiError alongside the statistics of what was copied.
+func CopyPaths(ctx context.Context, srcs []string, dst string, opts CopyOptions) (CopyDirResult, error) {
+	var result CopyDirResult
+	errs := newErrorList("copy", opts.ContinueOnError)
+	err := transferPaths("copy", srcs, dst, true, func(src, target string) error {
+		info, err := os.Stat(src)
+		if err != nil {
+			return errs.record(src, err)
+		}
+		if !info.IsDir() {
+			copied, err := CopyFileContext(ctx, src, target, opts)
+			result.Bytes += copied.Bytes
+			if err == nil {
+				result.Files++
+			} else if ctx.Err() == nil {
+				err = errs.record(src, err)
+			}
+			if err == errSkipped {
+				result.Failed++
+			}
+			return err
+		}
+		copied, err := CopyDirContext(ctx, src, target, opts)
+		result.Files += copied.Files
+		result.Dirs += copied.Dirs
+		result.Bytes += copied.Bytes
+		result.Hardlinks += copied.Hardlinks
+		result.Failed += copied.Failed
+		var multi *MultiError
+		if errors.As(err, &multi) && errs != nil {
+			errs.errs = append(errs.errs, multi.Errors...)
+			return errSkipped
+		}
+		return err
+	})
+	if err != nil {
+		return result, err
+	}
+	return result, errs.err(result.Files + result.Dirs + result.Hardlinks)
+}
+
+// MovePaths moves each of srcs to dst with the destination rules of mv(1),
+// which are those of CopyPaths except that a trailing separator on a source
+// makes no difference. Every move is done with MoveContext.
+func MovePaths(ctx context.Context, srcs []string, dst string, opts CopyOptions) error {
+	return transferPaths("move", srcs, dst, false, func(src, target string) error {
+		return MoveContext(ctx, src, target, opts)
+	})
+}
+
+// transferPaths works out the target of each of srcs when copied or moved
+// to dst and calls fn with it. contents makes a directory source ending in
+// a separator stand for its contents. fn returns errSkipped for a failure it
+// has recorded, and transferPaths carries on with the next source.
+func transferPaths(op string, srcs []string, dst string, contents bool, fn func(src, target string) error) error {
+	into := hasTrailingSeparator(dst)
+	if info, err := os.Stat(dst); err == nil && info.IsDir() {
+		into = true
+	}
+	if len(srcs) > 1 && !into {
+		return &os.PathError{Op: op, Path: dst, Err: syscall.ENOTDIR}
+	}
+	if into {
+		if err := os.MkdirAll(dst, 0755); err != nil {
+			return err
+		}
+	}
+
+	for _, src := range srcs {
+		if isGlob(src) {
+			if err := forGlob(op, src, dst, fn); err != nil && err != errSkipped {
+				return err
+			}
+			continue
+		}
+		target := dst
+		if into && !(contents && hasTrailingSeparator(src) && DirExists(src)) {
+			target = filepath.Join(dst, filepath.Base(src))
+		}
+		if err := checkTarget(op, src, target); err != nil {
+			return err
+		}
+		if err := fn(src, target); err != nil && err != errSkipped {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkTarget refuses to copy or move src onto itself or a directory into
+// its own tree.
+func checkTarget(op, src, target string) error {
+	srcInfo, err := os.Stat(src)
+	if err != nil {
+		return nil // reported by the operation itself
+	}
+	if targetInfo, err := os.Stat(target); err == nil && os.SameFile(srcInfo, targetInfo) {
+		// Renaming to the same file under another case is fine.
+		if op == "copy" || filepath.Clean(src) == filepath.Clean(target) {
+			return fmt.Errorf("'%s' and '%s' are the same file", src, target)
+		}
+	}
+	if !srcInfo.IsDir() {
+		return nil
+	}
+	absSrc, err := filepath.Abs(src)
+	if err != nil {
+		return err
+	}
+	absTarget, err := filepath.Abs(target)
+	if err != nil {
+		return err
+	}
+	if rel, err := filepath.Rel(absSrc, absTarget); err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("cannot %s '%s' into itself, '%s'", op, src, target)
+	}
+	return nil
+}
+
+// hasTrailingSeparator reports whether path ends in a path separator.
+func hasTrailingSeparator(path string) bool {
+	return len(path) > 1 && os.IsPathSeparator(path[len(path)-1])
+}
+
+// MoveContext moves a file or directory from src to dst.
+// When a rename is impossible because src and dst are on different filesystems,
+// it copies src with the given options and removes it afterwards; that slow path
+// reports progress to opts.Progress and stops with ctx.Err() if ctx is cancelled
+// during the copy, leaving src in place.
+// A file at dst is first kept as opts.Backup says, or with ConflictRename in
+// opts.Conflict left alone while src moves to the next free name; use
+// MoveFileWithOptions to learn that name.
+func MoveContext(ctx context.Context, src, dst string, opts CopyOptions) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if opts.Conflict.Policy == ConflictRename {
+		_, err := moveTo(ctx, src, dst, opts)
+		return err
+	}
+	// Nothing is backed up for a move that cannot start, and the backup is
+	// put back if the move fails.
+	if _, err := os.Lstat(src); err != nil {
+		return err
+	}
+	backup, err := BackupFile(dst, opts.Backup)
+	if err != nil {
+		return err
+	}
+	err = os.Rename(src, dst)
+	if errors.Is(err, syscall.EXDEV) {
+		err = moveAcross(ctx, src, dst, opts)
+	}
+	if err != nil {
+		restoreBackup(backup, dst)
+	}
+	return err
+}
+
+// moveAcross moves src to dst on another filesystem by copying and removing
+// it. Modes are kept and symlinks are moved as links, as a rename would.
+// Once the copy is complete, src is removed even if ctx is cancelled, so a
+// move is never left half done.
+func moveAcross(ctx context.Context, src, dst string, opts CopyOptions) error {
+	info, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+	opts.move = true
+	if info.Mode()&os.ModeSymlink != 0 {
+		if err := copySymlink(src, dst, newTracker(ctx, opts.Progress)); err != nil {
+			return err
+		}
+		return os.Remove(src)
+	}
+	if info.IsDir() {
+		if _, err := CopyDirContext(ctx, src, dst, opts); err != nil {
+			return err
+		}
+		// src has been copied, so removing it is part of the move.
+		return RmDirContext(context.Background(), src, RemoveOptions{Guard: GuardOptions{Unsafe: true}})
+	}
+	if _, err := CopyFileContext(ctx, src, dst, opts); err != nil {
+		return err
+	}
+	return os.Remove(src)
 }
 
 // getCreatedTime returns the creation time of a file or directory.
@@ -0,0 +1,167 @@
+package fsutils_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/utsav-56/go_fs_utils/fsutils"
+)
+
+func TestCopyPaths(t *testing.T) {
+	// Create a temporary directory for our tests
+	tempDir, err := os.MkdirTemp("", "fsutils-copypaths-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	src := filepath.Join(tempDir, "src")
+	fsutils.Mkdir(filepath.Join(src, "sub"))
+	os.WriteFile(filepath.Join(src, "sub", "inner.txt"), []byte("inner"), 0644)
+	file := filepath.Join(tempDir, "a.txt")
+	os.WriteFile(file, []byte("a"), 0644)
+	existing := filepath.Join(tempDir, "existing")
+	fsutils.Mkdir(existing)
+
+	exists := func(rel string) bool {
+		_, err := os.Stat(filepath.Join(tempDir, filepath.FromSlash(rel)))
+		return err == nil
+	}
+
+	// Test copying a file into an existing directory
+	if err := fsutils.Cp(file, existing); err != nil {
+		t.Fatalf("Cp into a directory failed: %v", err)
+	}
+	if !exists("existing/a.txt") {
+		t.Errorf("Cp did not place the file under its base name")
+	}
+
+	// Test copying a directory into an existing directory nests it
+	if err := fsutils.Cp(src, existing); err != nil {
+		t.Fatalf("Cp of a directory failed: %v", err)
+	}
+	if !exists("existing/src/sub/inner.txt") {
+		t.Errorf("Cp did not nest the directory")
+	}
+
+	// Test a trailing separator copies the contents
+	contents := filepath.Join(tempDir, "contents")
+	fsutils.Mkdir(contents)
+	if err := fsutils.Cp(src+string(filepath.Separator), contents); err != nil {
+		t.Fatalf("Cp of directory contents failed: %v", err)
+	}
+	if !exists("contents/sub/inner.txt") || exists("contents/src") {
+		t.Errorf("Cp with a trailing separator did not copy the contents")
+	}
+
+	// Test a new destination ending in a separator is created as a directory
+	result, err := fsutils.CopyPaths(context.Background(), []string{file, src}, filepath.Join(tempDir, "new")+string(filepath.Separator), fsutils.CopyOptions{})
+	if err != nil {
+		t.Fatalf("CopyPaths failed: %v", err)
+	}
+	if !exists("new/a.txt") || !exists("new/src/sub/inner.txt") || result.Files != 2 {
+		t.Errorf("CopyPaths copied %d files", result.Files)
+	}
+
+	// Test several sources need a directory target
+	if _, err := fsutils.CopyPaths(context.Background(), []string{file, src}, filepath.Join(tempDir, "missing"), fsutils.CopyOptions{}); err == nil {
+		t.Errorf("CopyPaths of several sources to a missing target succeeded")
+	}
+
+	// Test copying a directory into itself fails
+	if err := fsutils.Cp(src, filepath.Join(src, "sub")); err == nil {
+		t.Errorf("Cp of a directory into itself succeeded")
+	}
+	if err := fsutils.Cp(file, file); err == nil {
+		t.Errorf("Cp of a file onto itself succeeded")
+	}
+
+	// Test moving several sources into a directory
+	moved := filepath.Join(tempDir, "moved")
+	fsutils.Mkdir(moved)
+	if err := fsutils.MovePaths(context.Background(), []string{file, src}, moved, fsutils.CopyOptions{}); err != nil {
+		t.Fatalf("MovePaths failed: %v", err)
+	}
+	if !exists("moved/a.txt") || !exists("moved/src/sub/inner.txt") || exists("a.txt") || exists("src") {
+		t.Errorf("MovePaths did not move the sources into the directory")
+	}
+	if err := fsutils.Mv(filepath.Join(moved, "src"), filepath.Join(moved, "src", "sub")); err == nil {
+		t.Errorf("Mv of a directory into itself succeeded")
+	}
+
+	// Test glob matches get the same checks as plain sources
+	if err := fsutils.Cp(filepath.Join(tempDir, "mov*"), moved); err == nil || exists("moved/moved") {
+		t.Errorf("Cp of a matched directory into itself returned %v", err)
+	}
+	if err := fsutils.Mv(filepath.Join(moved, "*.txt"), moved); err == nil {
+		t.Errorf("Mv of a matched file onto itself succeeded")
+	}
+	if data, _ := os.ReadFile(filepath.Join(moved, "a.txt")); string(data) != "a" {
+		t.Errorf("Glob onto itself changed the file: %q", data)
+	}
+}
+
+func TestMoveAcrossFilesystems(t *testing.T) {
+	// Create temporary directories on two filesystems for our tests
+	tempDir, err := os.MkdirTemp("", "fsutils-move-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+	otherDir, err := os.MkdirTemp("/dev/shm", "fsutils-move-test")
+	if err != nil {
+		t.Skip("no second filesystem available")
+	}
+	defer os.RemoveAll(otherDir)
+	if os.Rename(otherDir, filepath.Join(tempDir, "probe")) == nil {
+		t.Skip("no second filesystem available")
+	}
+
+	src := filepath.Join(tempDir, "src")
+	fsutils.Mkdir(src)
+	os.WriteFile(filepath.Join(src, "script.sh"), []byte("#!/bin/sh"), 0750)
+	os.WriteFile(filepath.Join(src, "secret.txt"), []byte("secret"), 0600)
+	os.Symlink("secret.txt", filepath.Join(src, "link"))
+	os.Symlink("missing", filepath.Join(src, "dangling"))
+
+	// Test a move cancelled while copying leaves the source in place
+	ctx, cancel := context.WithCancel(context.Background())
+	cancelled := fsutils.CopyOptions{Progress: func(fsutils.Progress) { cancel() }}
+	if err := fsutils.MoveContext(ctx, src, filepath.Join(otherDir, "cancelled"), cancelled); err != context.Canceled {
+		t.Errorf("Cancelled MoveContext returned %v", err)
+	}
+	if !fsutils.FileExists(filepath.Join(src, "secret.txt")) {
+		t.Errorf("Cancelled MoveContext removed the source")
+	}
+
+	// Test a directory keeps its modes and symlinks
+	dst := filepath.Join(otherDir, "dst")
+	if err := fsutils.MoveContext(context.Background(), src, dst, fsutils.CopyOptions{}); err != nil {
+		t.Fatalf("MoveContext across filesystems failed: %v", err)
+	}
+	for name, want := range map[string]os.FileMode{"script.sh": 0750, "secret.txt": 0600} {
+		if info, err := os.Stat(filepath.Join(dst, name)); err != nil || info.Mode().Perm() != want {
+			t.Errorf("moved %s has mode %v, want %v (%v)", name, info.Mode().Perm(), want, err)
+		}
+	}
+	for name, want := range map[string]string{"link": "secret.txt", "dangling": "missing"} {
+		if target, err := os.Readlink(filepath.Join(dst, name)); err != nil || target != want {
+			t.Errorf("moved %s is not a symlink to %s: %q, %v", name, want, target, err)
+		}
+	}
+	if fsutils.DirExists(src) {
+		t.Errorf("MoveContext across filesystems left the source behind")
+	}
+
+	// Test a single symlink is moved as a link
+	link := filepath.Join(tempDir, "link")
+	os.Symlink("/etc/hostname", link)
+	if err := fsutils.MoveContext(context.Background(), link, filepath.Join(otherDir, "link"), fsutils.CopyOptions{}); err != nil {
+		t.Fatalf("MoveContext of a symlink failed: %v", err)
+	}
+	if info, err := os.Lstat(filepath.Join(otherDir, "link")); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("MoveContext of a symlink did not keep the link: %v", err)
+	}
+}
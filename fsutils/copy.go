@@ -0,0 +1,231 @@
+// Package fsutils provides filesystem utility functions for Go applications.
+package fsutils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// ErrQuotaExceeded is returned when a copy would write more than CopyOptions.Quota.
+var ErrQuotaExceeded = errors.New("copy quota exceeded")
+
+// CopyMethod identifies the mechanism used to transfer file data.
+type CopyMethod int
+
+const (
+	// CopyMethodBuffered copies data through a userspace buffer.
+	CopyMethodBuffered CopyMethod = iota
+	// CopyMethodReflink shares the source extents with a copy-on-write clone.
+	CopyMethodReflink
+	// CopyMethodCopyFileRange copies data inside the kernel with copy_file_range(2).
+	CopyMethodCopyFileRange
+	// CopyMethodSendfile copies data inside the kernel with sendfile(2).
+	CopyMethodSendfile
+	// CopyMethodSparse copies only the data regions of a sparse file and
+	// leaves holes in the destination.
+	CopyMethodSparse
+)
+
+// String returns a short name for the copy method.
+func (m CopyMethod) String() string {
+	switch m {
+	case CopyMethodReflink:
+		return "reflink"
+	case CopyMethodCopyFileRange:
+		return "copy_file_range"
+	case CopyMethodSendfile:
+		return "sendfile"
+	case CopyMethodSparse:
+		return "sparse"
+	default:
+		return "buffered"
+	}
+}
+
+// CloneMode controls whether a copy may, must, or must not be a copy-on-write clone.
+type CloneMode int
+
+const (
+	// CloneAuto tries a reflink first and silently falls back to a real copy.
+	CloneAuto CloneMode = iota
+	// CloneRequire fails the copy if a reflink cannot be made.
+	CloneRequire
+	// CloneForbid always writes an independent copy of the data.
+	// copy_file_range is skipped too, since the kernel may clone extents behind it.
+	CloneForbid
+)
+
+// CopyOptions controls the behaviour of the copy and move functions that take options.
+// The zero value gives the same behaviour as CopyFile and CopyDir.
+type CopyOptions struct {
+	// Clone selects the copy-on-write policy.
+	Clone CloneMode
+	// PreserveHardlinks makes CopyDir recreate hardlinks between source files
+	// in the destination instead of writing a separate copy for each path.
+	PreserveHardlinks bool
+	// Progress, if set, receives progress updates while data is copied.
+	Progress ProgressFunc
+	// PreScan walks a source directory before copying it so that progress
+	// updates carry totals. It costs one extra traversal of the tree.
+	PreScan bool
+	// RateLimit, if set, throttles the bytes and files copied per second.
+	// Reflinks and recreated hardlinks move no data and are not throttled.
+	RateLimit *RateLimiter
+	// Workers is the number of files CopyDir copies concurrently.
+	// Defaults to runtime.NumCPU().
+	Workers int
+	// MaxOpenFiles caps the file descriptors CopyDir holds open at once by
+	// lowering Workers as needed; every file copy uses two. Zero means no cap.
+	MaxOpenFiles int
+	// ContinueOnError makes CopyDir skip paths that cannot be copied and carry
+	// on with the rest of the tree. The failures are returned together as a
+	// *MultiError alongside the statistics of what was copied.
+	ContinueOnError bool
+	// Quota, if non-zero, caps the bytes a copy may write. A file that would
+	// take the total over it fails with ErrQuotaExceeded before it is opened,
+	// and with PreScan a tree that does not fit fails before anything is copied.
+	Quota Size
+	// Ignore and IgnoreFile leave paths out of a directory copy, as they do
+	// for Walk.
+	Ignore     *IgnoreMatcher
+	IgnoreFile string
+	// Backup, if its Mode is set, keeps every file that a copy or move
+	// would overwrite.
+	Backup BackupOptions
+	// Conflict, if its Policy is ConflictRename, writes files to the next
+	// free name instead of overwriting what is at the destination.
+	Conflict ConflictOptions
+
+	// move is set by MoveContext when it copies across filesystems: files
+	// keep their source mode and symlinks are recreated, not followed.
+	move bool
+}
+
+// CopyResult describes a completed file copy.
+type CopyResult struct {
+	Method CopyMethod // Mechanism that transferred the data
+	Bytes  int64      // Number of bytes copied
+	Path   string     // Destination written, which differs from dst after a ConflictRename
+}
+
+// CopyDirResult describes a completed directory copy.
+type CopyDirResult struct {
+	Files     int   // Number of files copied
+	Dirs      int   // Number of directories created
+	Bytes     int64 // Number of bytes copied
+	Hardlinks int   // Number of files recreated as hardlinks
+	Failed    int   // Number of paths skipped because of errors
+}
+
+// copyBufferSize is the buffer size used by the buffered fallback copy.
+const copyBufferSize = 128 * 1024
+
+// CopyFileWithOptions copies a file from src to dst using the fastest mechanism available.
+// On Linux it tries a FICLONE reflink, then copy_file_range, then sendfile, then a buffered copy.
+// Holes in sparse source files are reproduced in the destination rather than filled with zeros.
+func CopyFileWithOptions(src, dst string, opts CopyOptions) (CopyResult, error) {
+	return CopyFileContext(context.Background(), src, dst, opts)
+}
+
+// CopyFileContext is like CopyFileWithOptions but stops with ctx.Err() once
+// ctx is cancelled and reports progress to opts.Progress while copying.
+// A cancelled copy leaves a partial destination file behind, except with
+// ConflictRename, where the file it created is removed again.
+func CopyFileContext(ctx context.Context, src, dst string, opts CopyOptions) (CopyResult, error) {
+	t := newTracker(ctx, opts.Progress)
+	t.limit, t.quota = opts.RateLimit, opts.Quota
+	if info, err := os.Stat(src); err == nil {
+		t.progress.FilesTotal, t.progress.BytesTotal = 1, info.Size()
+	}
+	return copyFile(src, dst, opts, t)
+}
+
+// copyFile copies src to dst, recording progress in t.
+func copyFile(src, dst string, opts CopyOptions, t *tracker) (CopyResult, error) {
+	var result CopyResult
+	if err := t.start(src); err != nil {
+		return result, err
+	}
+	srcFile, err := os.Open(src)
+	if err != nil {
+		return result, err
+	}
+	defer srcFile.Close()
+
+	info, err := srcFile.Stat()
+	if err != nil {
+		return result, err
+	}
+	if info.IsDir() {
+		return result, fmt.Errorf("'%s' is a directory, use CopyDir or Cp", src)
+	}
+	if err := t.reserve(src, info.Size()); err != nil {
+		return result, err
+	}
+
+	var dstFile *os.File
+	backup := ""
+	if opts.Conflict.Policy == ConflictRename {
+		dstFile, err = CreateUnique(dst, opts.Conflict.Template)
+	} else if backup, err = BackupFile(dst, opts.Backup); err == nil {
+		dstFile, err = os.Create(dst)
+	}
+	if err != nil {
+		restoreBackup(backup, dst)
+		return result, err
+	}
+	result.Path = dstFile.Name()
+	result.Method, result.Bytes, err = copyFileData(dstFile, srcFile, info.Size(), opts, t)
+	if closeErr := dstFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && opts.move {
+		err = os.Chmod(result.Path, info.Mode().Perm())
+	}
+	if err != nil {
+		if opts.Conflict.Policy == ConflictRename {
+			// The name was only taken for this copy.
+			os.Remove(result.Path)
+			result.Path = ""
+		}
+		restoreBackup(backup, dst)
+		return result, err
+	}
+	return result, t.addFile()
+}
+
+// copyFileData transfers the contents of src into the empty file dst.
+// Each fast path reports whether it applied, so a method that is unsupported
+// for this pair of files falls through to the next one without side effects.
+func copyFileData(dst, src *os.File, size int64, opts CopyOptions, t *tracker) (CopyMethod, int64, error) {
+	if opts.Clone != CloneForbid {
+		err := reflink(src, dst)
+		if err == nil {
+			return CopyMethodReflink, size, t.skipBytes(size)
+		}
+		if opts.Clone == CloneRequire {
+			return CopyMethodReflink, 0, fmt.Errorf("cannot clone '%s': %w", src.Name(), err)
+		}
+	}
+	if segments, ok := dataSegments(src, size); ok {
+		n, err := copySparse(dst, src, segments, size, t)
+		return CopyMethodSparse, n, err
+	}
+	if opts.Clone != CloneForbid {
+		if n, ok, err := copyFileRange(dst, src, t); ok {
+			return CopyMethodCopyFileRange, n, err
+		}
+	}
+	if n, ok, err := sendfile(dst, src, t); ok {
+		return CopyMethodSendfile, n, err
+	}
+	n, err := io.CopyBuffer(progressWriter{dst, t}, readerOnly{src}, make([]byte, copyBufferSize))
+	return CopyMethodBuffered, n, err
+}
+
+// readerOnly hides the WriteTo method of *os.File so io.Copy really goes
+// through the userspace buffer.
+type readerOnly struct{ io.Reader }
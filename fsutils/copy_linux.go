@@ -0,0 +1,103 @@
+//go:build linux
+
+// Package fsutils provides filesystem utility functions for Go applications.
+package fsutils
+
+import (
+	"os"
+	"runtime"
+	"syscall"
+)
+
+// sysCopyFileRange is the copy_file_range(2) syscall number for the running
+// architecture, or zero where the frozen syscall package does not know it.
+var sysCopyFileRange = map[string]uintptr{
+	"386":      377,
+	"amd64":    326,
+	"arm":      391,
+	"arm64":    285,
+	"loong64":  285,
+	"ppc64":    379,
+	"ppc64le":  379,
+	"riscv64":  285,
+	"s390x":    375,
+	"mips64":   5320,
+	"mips64le": 5320,
+}[runtime.GOARCH]
+
+// kernelCopyChunk is the size of a single in-kernel copy request. Keeping it
+// moderate lets long copies report progress and notice cancellation.
+const kernelCopyChunk = 8 << 20
+
+// kernelChunk returns the request size to use for t; rate-limited copies use
+// buffer-sized requests so that the limiter sees a smooth stream.
+func kernelChunk(t *tracker) uintptr {
+	if t.limit != nil {
+		return copyBufferSize
+	}
+	return kernelCopyChunk
+}
+
+// fallbackErrno reports whether errno means an in-kernel copy cannot be used
+// for this pair of files and a slower method should be tried instead.
+func fallbackErrno(errno syscall.Errno) bool {
+	switch errno {
+	case syscall.ENOSYS, syscall.EXDEV, syscall.EINVAL, syscall.EOPNOTSUPP,
+		syscall.EPERM, syscall.EBADF:
+		return true
+	}
+	return false
+}
+
+// copyFileRange copies src to dst from their current offsets with copy_file_range(2).
+// ok is false when the syscall is unavailable and nothing has been written.
+func copyFileRange(dst, src *os.File, t *tracker) (written int64, ok bool, err error) {
+	if sysCopyFileRange == 0 {
+		return 0, false, nil
+	}
+	for {
+		n, _, errno := syscall.Syscall6(sysCopyFileRange, src.Fd(), 0, dst.Fd(), 0, kernelChunk(t), 0)
+		if errno == syscall.EINTR {
+			continue
+		}
+		if errno != 0 {
+			if written == 0 && fallbackErrno(errno) {
+				return 0, false, nil
+			}
+			return written, true, &os.PathError{Op: "copy_file_range", Path: src.Name(), Err: errno}
+		}
+		if n == 0 {
+			// Some pseudo filesystems report no data here even though a
+			// read(2) would return some; let a slower method handle them.
+			return written, written > 0, nil
+		}
+		written += int64(n)
+		if err := t.addBytes(int64(n)); err != nil {
+			return written, true, err
+		}
+	}
+}
+
+// sendfile copies src to dst from their current offsets with sendfile(2).
+// ok is false when the syscall cannot be used and nothing has been written.
+func sendfile(dst, src *os.File, t *tracker) (written int64, ok bool, err error) {
+	for {
+		n, err := syscall.Sendfile(int(dst.Fd()), int(src.Fd()), nil, int(kernelChunk(t)))
+		if err == syscall.EINTR || err == syscall.EAGAIN {
+			continue
+		}
+		if err != nil {
+			if errno, isErrno := err.(syscall.Errno); written == 0 && isErrno && fallbackErrno(errno) {
+				return 0, false, nil
+			}
+			return written, true, &os.PathError{Op: "sendfile", Path: src.Name(), Err: err}
+		}
+		if n == 0 {
+			return written, written > 0, nil
+		}
+		written += int64(n)
+		if err := t.addBytes(int64(n)); err != nil {
+			return written, true, err
+		}
+	}
+}
@@ -0,0 +1,16 @@
+//go:build !linux
+
+// Package fsutils provides filesystem utility functions for Go applications.
+package fsutils
+
+import "os"
+
+// copyFileRange is not available on this platform.
+func copyFileRange(dst, src *os.File, t *tracker) (written int64, ok bool, err error) {
+	return 0, false, nil
+}
+
+// sendfile is not available on this platform.
+func sendfile(dst, src *os.File, t *tracker) (written int64, ok bool, err error) {
+	return 0, false, nil
+}
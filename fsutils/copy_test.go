@@ -0,0 +1,162 @@
+package fsutils_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/utsav-56/go_fs_utils/fsutils"
+)
+
+func TestCopyFileWithOptions(t *testing.T) {
+	// Create a temporary directory for our tests
+	tempDir, err := os.MkdirTemp("", "fsutils-copy-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	content := bytes.Repeat([]byte("fsutils copy "), 100000)
+	src := filepath.Join(tempDir, "src.bin")
+	if err := os.WriteFile(src, content, 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	// Test every clone mode produces an identical copy
+	modes := []fsutils.CloneMode{fsutils.CloneAuto, fsutils.CloneForbid}
+	for i, mode := range modes {
+		dst := filepath.Join(tempDir, "dst"+string(rune('0'+i)))
+		result, err := fsutils.CopyFileWithOptions(src, dst, fsutils.CopyOptions{Clone: mode})
+		if err != nil {
+			t.Fatalf("CopyFileWithOptions failed: %v", err)
+		}
+		if result.Bytes != int64(len(content)) {
+			t.Errorf("CopyFileWithOptions reported wrong size: got %d, want %d", result.Bytes, len(content))
+		}
+		if mode == fsutils.CloneForbid && result.Method == fsutils.CopyMethodReflink {
+			t.Errorf("CopyFileWithOptions made a reflink despite CloneForbid")
+		}
+		got, err := os.ReadFile(dst)
+		if err != nil {
+			t.Fatalf("Failed to read copy: %v", err)
+		}
+		if !bytes.Equal(got, content) {
+			t.Errorf("CopyFileWithOptions produced different content using %v", result.Method)
+		}
+	}
+
+	// Test CloneRequire either clones or fails
+	result, err := fsutils.CopyFileWithOptions(src, filepath.Join(tempDir, "clone"), fsutils.CopyOptions{Clone: fsutils.CloneRequire})
+	if err == nil && result.Method != fsutils.CopyMethodReflink {
+		t.Errorf("CloneRequire copied with %v", result.Method)
+	}
+
+	// Test copying a directory fails
+	if _, err := fsutils.CopyFileWithOptions(tempDir, filepath.Join(tempDir, "dir"), fsutils.CopyOptions{}); err == nil {
+		t.Errorf("CopyFileWithOptions accepted a directory source")
+	}
+}
+
+func TestCopyDirPreserveHardlinks(t *testing.T) {
+	// Create a temporary directory for our tests
+	tempDir, err := os.MkdirTemp("", "fsutils-copydir-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	src := filepath.Join(tempDir, "src")
+	if err := fsutils.Mkdir(filepath.Join(src, "bin")); err != nil {
+		t.Fatalf("Failed to create test directory: %v", err)
+	}
+	tool := filepath.Join(src, "bin", "tool")
+	if err := os.WriteFile(tool, []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	if err := os.Link(tool, filepath.Join(src, "bin", "tool-alias")); err != nil {
+		t.Skipf("Hardlinks are not supported here: %v", err)
+	}
+
+	// Test CopyDirWithOptions recreates the link
+	dst := filepath.Join(tempDir, "dst")
+	result, err := fsutils.CopyDirWithOptions(src, dst, fsutils.CopyOptions{PreserveHardlinks: true})
+	if err != nil {
+		t.Fatalf("Failed to copy directory: %v", err)
+	}
+	if result.Files != 1 || result.Hardlinks != 1 || result.Dirs != 2 {
+		t.Errorf("CopyDirWithOptions returned wrong statistics: %+v", result)
+	}
+	a, _ := os.Stat(filepath.Join(dst, "bin", "tool"))
+	b, _ := os.Stat(filepath.Join(dst, "bin", "tool-alias"))
+	if a == nil || b == nil || !os.SameFile(a, b) {
+		t.Errorf("CopyDirWithOptions didn't preserve the hardlink")
+	}
+
+	// Test plain CopyDir still writes separate copies
+	plain := filepath.Join(tempDir, "plain")
+	if err := fsutils.CopyDir(src, plain); err != nil {
+		t.Fatalf("Failed to copy directory: %v", err)
+	}
+	a, _ = os.Stat(filepath.Join(plain, "bin", "tool"))
+	b, _ = os.Stat(filepath.Join(plain, "bin", "tool-alias"))
+	if a == nil || b == nil || os.SameFile(a, b) {
+		t.Errorf("CopyDir linked files without PreserveHardlinks")
+	}
+}
+
+func TestCopyDirConcurrent(t *testing.T) {
+	// Create a temporary directory for our tests
+	tempDir, err := os.MkdirTemp("", "fsutils-copydir-workers-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	// Build a tree of 5 directories holding 20 files each
+	src := filepath.Join(tempDir, "src")
+	for d := 0; d < 5; d++ {
+		dir := filepath.Join(src, "dir"+string(rune('a'+d)), "nested")
+		if err := fsutils.Mkdir(dir); err != nil {
+			t.Fatalf("Failed to create test directory: %v", err)
+		}
+		for f := 0; f < 20; f++ {
+			name := filepath.Join(dir, "file"+string(rune('a'+f)))
+			if err := os.WriteFile(name, []byte(name), 0644); err != nil {
+				t.Fatalf("Failed to write test file: %v", err)
+			}
+		}
+	}
+
+	// Test copying with a worker pool and a descriptor budget
+	dst := filepath.Join(tempDir, "dst")
+	result, err := fsutils.CopyDirWithOptions(src, dst, fsutils.CopyOptions{Workers: 8, MaxOpenFiles: 6})
+	if err != nil {
+		t.Fatalf("Failed to copy directory: %v", err)
+	}
+	if result.Files != 100 || result.Dirs != 11 {
+		t.Errorf("CopyDirWithOptions returned wrong statistics: %+v", result)
+	}
+	err = filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, _ := filepath.Rel(src, path)
+		got, err := os.ReadFile(filepath.Join(dst, rel))
+		if err != nil {
+			return err
+		}
+		if string(got) != path {
+			t.Errorf("CopyDirWithOptions copied wrong content to %s", rel)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Errorf("CopyDirWithOptions missed files: %v", err)
+	}
+
+	// Test a missing source fails cleanly
+	if _, err := fsutils.CopyDirWithOptions(filepath.Join(tempDir, "missing"), dst, fsutils.CopyOptions{Workers: 4}); err == nil {
+		t.Errorf("CopyDirWithOptions accepted a missing source")
+	}
+}
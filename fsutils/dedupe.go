@@ -0,0 +1,239 @@
+// Package fsutils provides filesystem utility functions for Go applications.
+package fsutils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// DedupeMode selects how Dedupe replaces duplicate files.
+type DedupeMode int
+
+const (
+	// DedupeAuto uses a reflink where the filesystem supports it and falls
+	// back to a hardlink otherwise.
+	DedupeAuto DedupeMode = iota
+	// DedupeHardlink replaces duplicates with hardlinks to the kept file.
+	DedupeHardlink
+	// DedupeReflink replaces duplicates with copy-on-write clones of the kept file.
+	DedupeReflink
+)
+
+// DedupeOptions controls the behaviour of Dedupe.
+type DedupeOptions struct {
+	// Mode selects between hardlinks and reflinks.
+	Mode DedupeMode
+	// RequireSameMode skips duplicates whose permission bits differ from the kept file.
+	RequireSameMode bool
+	// RequireSameOwner skips duplicates whose owner or group differ from the kept file.
+	RequireSameOwner bool
+	// DryRun reports what would be replaced without touching any file.
+	DryRun bool
+}
+
+// DedupeReport summarises the work done (or planned, in dry-run mode) by Dedupe.
+type DedupeReport struct {
+	Groups         int      // Number of groups of identical files found
+	Replaced       []string // Paths that were (or would be) replaced by a link
+	Skipped        []string // Paths left alone because of mode/owner/device mismatches or changes since hashing
+	BytesReclaimed int64    // Bytes freed by the replacements, counting each inode once
+}
+
+// errDedupeChanged reports that a file changed between hashing and replacing.
+var errDedupeChanged = errors.New("file changed since it was hashed")
+
+// FindDuplicates walks root and groups regular, non-empty files with identical content.
+// Each group holds two or more sorted paths; groups are ordered by their first path.
+func FindDuplicates(root string) ([][]string, error) {
+	bySize := make(map[int64][]string)
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() && info.Size() > 0 {
+			bySize[info.Size()] = append(bySize[info.Size()], path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var groups [][]string
+	for _, paths := range bySize {
+		if len(paths) < 2 {
+			continue
+		}
+		byHash := make(map[string][]string)
+		for _, path := range paths {
+			sum, err := HashFile(path)
+			if err != nil {
+				return nil, err
+			}
+			byHash[sum] = append(byHash[sum], path)
+		}
+		for _, group := range byHash {
+			if len(group) > 1 {
+				sort.Strings(group)
+				groups = append(groups, group)
+			}
+		}
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i][0] < groups[j][0] })
+	return groups, nil
+}
+
+// Dedupe replaces identical files under root with hardlinks or reflinks to a single copy.
+// The first path of each group is kept; every other file keeps its own name.
+// Files are hashed again just before a replacement, and a file
+// that changed since it was hashed is skipped rather than replaced.
+func Dedupe(root string, opts DedupeOptions) (DedupeReport, error) {
+	var report DedupeReport
+	type inode struct{ dev, ino uint64 }
+	reclaimed := make(map[inode]bool)
+	groups, err := FindDuplicates(root)
+	if err != nil {
+		return report, err
+	}
+	report.Groups = len(groups)
+
+	for _, group := range groups {
+		keep := group[0]
+		keepInfo, err := os.Stat(keep)
+		if err != nil {
+			return report, err
+		}
+		for _, dup := range group[1:] {
+			dupInfo, err := os.Stat(dup)
+			if err != nil {
+				return report, err
+			}
+			if os.SameFile(keepInfo, dupInfo) {
+				continue
+			}
+			if !dedupeCompatible(keepInfo, dupInfo, opts) {
+				report.Skipped = append(report.Skipped, dup)
+				continue
+			}
+			if !opts.DryRun {
+				err := replaceDuplicate(keep, dup, dupInfo, opts.Mode)
+				if err == errDedupeChanged {
+					report.Skipped = append(report.Skipped, dup)
+					continue
+				}
+				if err != nil {
+					return report, err
+				}
+			}
+			report.Replaced = append(report.Replaced, dup)
+			// Paths already hardlinked to each other free their data once.
+			if dev, ino, ok := fileDevIno(dupInfo); ok {
+				if reclaimed[inode{dev, ino}] {
+					continue
+				}
+				reclaimed[inode{dev, ino}] = true
+			}
+			report.BytesReclaimed += dupInfo.Size()
+		}
+	}
+	return report, nil
+}
+
+// dedupeCompatible reports whether dup may be replaced by a link to keep under opts.
+func dedupeCompatible(keep, dup os.FileInfo, opts DedupeOptions) bool {
+	if opts.RequireSameMode && keep.Mode().Perm() != dup.Mode().Perm() {
+		return false
+	}
+	if opts.RequireSameOwner {
+		ku, kg, kok := fileOwner(keep)
+		du, dg, dok := fileOwner(dup)
+		if !kok || !dok || ku != du || kg != dg {
+			return false
+		}
+	}
+	// Neither hardlinks nor reflinks can span filesystems.
+	kdev, _, kok := fileDevIno(keep)
+	ddev, _, dok := fileDevIno(dup)
+	return !kok || !dok || kdev == ddev
+}
+
+// replaceDuplicate atomically swaps dup for a link to keep.
+// The link is prepared next to dup and renamed over it, so dup never goes missing.
+func replaceDuplicate(keep, dup string, dupInfo os.FileInfo, mode DedupeMode) error {
+	tmp := filepath.Join(filepath.Dir(dup), "."+filepath.Base(dup)+".fsutils-dedupe")
+	os.Remove(tmp)
+
+	var err error
+	switch mode {
+	case DedupeHardlink:
+		err = os.Link(keep, tmp)
+	case DedupeReflink:
+		err = reflinkFile(keep, tmp, dupInfo)
+	default:
+		if err = reflinkFile(keep, tmp, dupInfo); err != nil {
+			os.Remove(tmp)
+			err = os.Link(keep, tmp)
+		}
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("cannot dedupe '%s': %w", dup, err)
+	}
+	// Either file may have been written to since it was hashed.
+	if same, err := unchangedDuplicate(keep, dup); err != nil || !same {
+		os.Remove(tmp)
+		if err != nil {
+			return err
+		}
+		return errDedupeChanged
+	}
+	if err := os.Rename(tmp, dup); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// reflinkFile creates dst as a copy-on-write clone of src carrying the
+// permissions and modification time described by info.
+func reflinkFile(src, dst string, info os.FileInfo) error {
+	srcFile, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer srcFile.Close()
+
+	dstFile, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if err := reflink(srcFile, dstFile); err != nil {
+		dstFile.Close()
+		return err
+	}
+	if err := dstFile.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(dst, info.Mode().Perm()); err != nil {
+		return err
+	}
+	return os.Chtimes(dst, info.ModTime(), info.ModTime())
+}
+
+// unchangedDuplicate hashes keep and dup again and reports whether they
+// still hold the same data.
+func unchangedDuplicate(keep, dup string) (bool, error) {
+	keepInfo, err := os.Stat(keep)
+	if err != nil {
+		return false, err
+	}
+	dupInfo, err := os.Stat(dup)
+	if err != nil {
+		return false, err
+	}
+	return sameContent(context.Background(), keep, dup, keepInfo, dupInfo, DiffOptions{Checksum: true})
+}
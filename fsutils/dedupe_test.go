@@ -0,0 +1,100 @@
+package fsutils_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/utsav-56/go_fs_utils/fsutils"
+)
+
+func TestDedupe(t *testing.T) {
+	// Create a temporary directory for our tests
+	tempDir, err := os.MkdirTemp("", "fsutils-dedupe-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	content := []byte("duplicate content")
+	a := filepath.Join(tempDir, "a.txt")
+	b := filepath.Join(tempDir, "b.txt")
+	c := filepath.Join(tempDir, "unique.txt")
+	for _, p := range []string{a, b} {
+		if err := os.WriteFile(p, content, 0644); err != nil {
+			t.Fatalf("Failed to write test file: %v", err)
+		}
+	}
+	if err := os.WriteFile(c, []byte("something else"), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	// Test FindDuplicates
+	groups, err := fsutils.FindDuplicates(tempDir)
+	if err != nil {
+		t.Fatalf("FindDuplicates failed: %v", err)
+	}
+	if len(groups) != 1 || len(groups[0]) != 2 {
+		t.Fatalf("FindDuplicates returned wrong groups: %v", groups)
+	}
+
+	// Test dry run leaves files untouched
+	report, err := fsutils.Dedupe(tempDir, fsutils.DedupeOptions{Mode: fsutils.DedupeHardlink, DryRun: true})
+	if err != nil {
+		t.Fatalf("Dedupe dry run failed: %v", err)
+	}
+	if report.BytesReclaimed != int64(len(content)) {
+		t.Errorf("Dedupe dry run reported wrong bytes: got %d, want %d", report.BytesReclaimed, len(content))
+	}
+	infoA, _ := os.Stat(a)
+	infoB, _ := os.Stat(b)
+	if os.SameFile(infoA, infoB) {
+		t.Errorf("Dedupe dry run linked files")
+	}
+
+	// Test hardlink dedupe
+	if _, err := fsutils.Dedupe(tempDir, fsutils.DedupeOptions{Mode: fsutils.DedupeHardlink}); err != nil {
+		t.Fatalf("Dedupe failed: %v", err)
+	}
+	infoA, _ = os.Stat(a)
+	infoB, _ = os.Stat(b)
+	if !os.SameFile(infoA, infoB) {
+		t.Errorf("Dedupe didn't hardlink %s and %s", a, b)
+	}
+
+	// A second run finds nothing left to reclaim
+	report, err = fsutils.Dedupe(tempDir, fsutils.DedupeOptions{Mode: fsutils.DedupeHardlink})
+	if err != nil {
+		t.Fatalf("Dedupe failed: %v", err)
+	}
+	if len(report.Replaced) != 0 {
+		t.Errorf("Dedupe replaced already linked files: %v", report.Replaced)
+	}
+}
+
+func TestDedupeCountsLinkedDuplicatesOnce(t *testing.T) {
+	tempDir := t.TempDir()
+	content := []byte("duplicate content")
+	a := filepath.Join(tempDir, "a.txt")
+	b := filepath.Join(tempDir, "b.txt")
+	c := filepath.Join(tempDir, "c.txt")
+	for _, p := range []string{a, b} {
+		if err := os.WriteFile(p, content, 0644); err != nil {
+			t.Fatalf("Failed to write test file: %v", err)
+		}
+	}
+	if err := os.Link(b, c); err != nil {
+		t.Skipf("hardlinks not supported: %v", err)
+	}
+
+	report, err := fsutils.Dedupe(tempDir, fsutils.DedupeOptions{Mode: fsutils.DedupeHardlink, DryRun: true})
+	if err != nil {
+		t.Fatalf("Dedupe dry run failed: %v", err)
+	}
+	if len(report.Replaced) != 2 {
+		t.Errorf("Dedupe dry run replaced %v, want b.txt and c.txt", report.Replaced)
+	}
+	if report.BytesReclaimed != int64(len(content)) {
+		t.Errorf("Dedupe counted linked duplicates twice: got %d bytes, want %d", report.BytesReclaimed, len(content))
+	}
+}
@@ -0,0 +1,170 @@
+// Package fsutils provides filesystem utility functions for Go applications.
+package fsutils
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// DiffKind classifies a difference between two trees.
+type DiffKind int
+
+const (
+	// DiffAdded marks a path that exists only in the second tree.
+	DiffAdded DiffKind = iota + 1
+	// DiffRemoved marks a path that exists only in the first tree.
+	DiffRemoved
+	// DiffModified marks a file or symlink whose content or target differs.
+	DiffModified
+	// DiffTypeChanged marks a path that is a different kind of entry in
+	// each tree, such as a file in one and a directory in the other.
+	DiffTypeChanged
+)
+
+// String returns a short name for the kind of difference.
+func (k DiffKind) String() string {
+	switch k {
+	case DiffAdded:
+		return "added"
+	case DiffRemoved:
+		return "removed"
+	case DiffModified:
+		return "modified"
+	case DiffTypeChanged:
+		return "type-changed"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalText encodes the kind as its name, so it reads well in JSON.
+func (k DiffKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// DiffEntry is one difference reported by DiffDirs.
+type DiffEntry struct {
+	Path string   // Slash-separated path relative to both roots
+	Kind DiffKind // What differs
+}
+
+// DiffOptions controls the behaviour of DiffDirs.
+type DiffOptions struct {
+	// Checksum compares the contents of files of equal size instead of
+	// trusting their modification times. It reads both files in full.
+	Checksum bool
+	// Ignore and IgnoreFile leave paths out of the comparison, as they do
+	// for Walk. Ignore files are read from each tree.
+	Ignore     *IgnoreMatcher
+	IgnoreFile string
+}
+
+// DiffDirs compares the trees rooted at a and b and returns their
+// differences in depth-first path order, so parents come before their entries.
+// Files are compared by size and modification time, or by content with
+// opts.Checksum; directories are compared by their entries only.
+// It stops with ctx.Err() once ctx is cancelled.
+func DiffDirs(ctx context.Context, a, b string, opts DiffOptions) ([]DiffEntry, error) {
+	walkOpts := WalkOptions{Ignore: opts.Ignore, IgnoreFile: opts.IgnoreFile}
+	before, err := treeSnapshot(ctx, a, walkOpts)
+	if err != nil {
+		return nil, err
+	}
+	after, err := treeSnapshot(ctx, b, walkOpts)
+	if err != nil {
+		return nil, err
+	}
+	return diffTrees(ctx, a, b, before, after, opts)
+}
+
+// treeSnapshot returns the metadata of every path below root that opts
+// lets through, keyed by relative path. Symlinks are not followed.
+func treeSnapshot(ctx context.Context, root string, opts WalkOptions) (map[string]fs.FileInfo, error) {
+	snap := make(map[string]fs.FileInfo)
+	err := Walk(ctx, root, opts, func(entry WalkEntry) error {
+		if entry.Depth > 0 {
+			snap[entry.Rel] = entry.Info
+		}
+		return nil
+	})
+	return snap, err
+}
+
+// diffTrees compares two snapshots taken of the trees at a and b.
+func diffTrees(ctx context.Context, a, b string, before, after map[string]fs.FileInfo, opts DiffOptions) ([]DiffEntry, error) {
+	var diffs []DiffEntry
+	for rel, old := range before {
+		cur, ok := after[rel]
+		if !ok {
+			diffs = append(diffs, DiffEntry{Path: rel, Kind: DiffRemoved})
+			continue
+		}
+		if old.Mode().Type() != cur.Mode().Type() {
+			diffs = append(diffs, DiffEntry{Path: rel, Kind: DiffTypeChanged})
+			continue
+		}
+		same, err := sameContent(ctx, filepath.Join(a, filepath.FromSlash(rel)), filepath.Join(b, filepath.FromSlash(rel)), old, cur, opts)
+		if err != nil {
+			return nil, err
+		}
+		if !same {
+			diffs = append(diffs, DiffEntry{Path: rel, Kind: DiffModified})
+		}
+	}
+	for rel := range after {
+		if _, ok := before[rel]; !ok {
+			diffs = append(diffs, DiffEntry{Path: rel, Kind: DiffAdded})
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool { return pathLess(diffs[i].Path, diffs[j].Path) })
+	return diffs, nil
+}
+
+// pathLess orders slash-separated paths depth-first, so every directory is
+// directly followed by its entries ("a", "a/b", "a.txt").
+func pathLess(a, b string) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		ca, cb := a[i], b[i]
+		if ca == cb {
+			continue
+		}
+		if ca == '/' {
+			return true
+		}
+		if cb == '/' {
+			return false
+		}
+		return ca < cb
+	}
+	return len(a) < len(b)
+}
+
+// sameContent reports whether two entries of the same type hold the same data.
+func sameContent(ctx context.Context, pathA, pathB string, a, b fs.FileInfo, opts DiffOptions) (bool, error) {
+	switch {
+	case a.IsDir():
+		return true, nil
+	case a.Mode()&fs.ModeSymlink != 0:
+		targetA, err := os.Readlink(pathA)
+		if err != nil {
+			return false, err
+		}
+		targetB, err := os.Readlink(pathB)
+		return targetA == targetB, err
+	case !a.Mode().IsRegular():
+		return true, nil // devices, pipes and sockets have no content to compare
+	case a.Size() != b.Size():
+		return false, nil
+	case !opts.Checksum:
+		return a.ModTime().Equal(b.ModTime()), nil
+	}
+	sumA, err := HashFileContext(ctx, pathA, HashOptions{})
+	if err != nil {
+		return false, err
+	}
+	sumB, err := HashFileContext(ctx, pathB, HashOptions{})
+	return sumA == sumB, err
+}
@@ -2,11 +2,13 @@
 package fsutils
 
 import (
+	"context"
 	"fmt"
-	"io"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
+	"sync"
 )
 
 // DirExists checks if a directory exists at the given path.
@@ -71,10 +73,12 @@ func PathInfo(path string) map[string]interface{} {
 	infoMap["isDir"] = isDir
 	infoMap["isFile"] = isFile
 	infoMap["isExecutable"] = isExecutable
+	size := Size(info.Size())
 	infoMap["size"] = info.Size()
-	infoMap["sizeKb"] = float64(info.Size()) / 1024
-	infoMap["sizeMB"] = float64(info.Size()) / (1024 * 1024)
-	infoMap["sizeGB"] = float64(info.Size()) / (1024 * 1024 * 1024)
+	infoMap["sizeKb"] = size.In(KiB)
+	infoMap["sizeMB"] = size.In(MiB)
+	infoMap["sizeGB"] = size.In(GiB)
+	infoMap["sizeHuman"] = size.String()
 	infoMap["dateCreated"] = getCreatedTime(path, info)
 	infoMap["dateModified"] = info.ModTime()
 	infoMap["numChilds"] = childCount
@@ -120,10 +124,102 @@ func Mkdir(path string) error {
 
 // RmDir removes a directory and all its contents recursively.
 // Use with caution as this will delete all files and subdirectories.
+// Dangerous targets such as "", the home directory or the current directory
+// are refused; see CheckRemovable.
 func RmDir(path string) error {
+	if err := CheckRemovable(path, GuardOptions{}); err != nil {
+		return err
+	}
 	return os.RemoveAll(path)
 }
 
+// RemoveOptions controls the behaviour of RmDirContext.
+type RemoveOptions struct {
+	// Progress, if set, is called after every removed entry.
+	Progress ProgressFunc
+	// PreScan counts the entries first so that progress updates carry totals.
+	PreScan bool
+	// ContinueOnError keeps removing the rest of the tree when an entry cannot
+	// be removed. The failures are returned together as a *MultiError, and the
+	// directories containing them are left in place.
+	ContinueOnError bool
+	// Guard adjusts the CheckRemovable checks made on the path first.
+	Guard GuardOptions
+	// DryRun walks the tree without removing anything, so that OnRemove
+	// lists what would be deleted.
+	DryRun bool
+	// OnRemove, if set, is called with every path removed, or that would be
+	// with DryRun, the entries of a directory before the directory itself.
+	OnRemove func(path string)
+}
+
+// RmDirContext removes path and everything below it like RmDir, but stops
+// with ctx.Err() once ctx is cancelled and reports progress to opts.Progress.
+// A cancelled removal leaves the remaining entries in place. Like RmDir, it
+// refuses dangerous targets unless opts.Guard allows them.
+func RmDirContext(ctx context.Context, path string, opts RemoveOptions) error {
+	if err := CheckRemovable(path, opts.Guard); err != nil {
+		return err
+	}
+	t := newTracker(ctx, opts.Progress)
+	if opts.PreScan {
+		entries, _, err := scanTree(ctx, path, nil, "")
+		if err != nil && !os.IsNotExist(err) && !opts.ContinueOnError {
+			return err
+		}
+		t.progress.FilesTotal = entries
+	}
+	errs := newErrorList("remove", opts.ContinueOnError)
+	err := removeTree(path, opts, t, errs)
+	if err == errSkipped || os.IsNotExist(err) {
+		err = nil
+	}
+	if err != nil {
+		return err
+	}
+	return errs.err(t.progress.FilesDone)
+}
+
+// removeTree removes path depth-first, recording every entry in t.
+// Failures go through errs, so with ContinueOnError a failed entry only
+// stops the removal of the directories above it.
+func removeTree(path string, opts RemoveOptions, t *tracker, errs *errorList) error {
+	if err := t.start(path); err != nil {
+		return err
+	}
+	info, err := os.Lstat(path)
+	if err != nil {
+		return errs.record(path, err)
+	}
+	if info.IsDir() {
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return errs.record(path, err)
+		}
+		failed := false
+		for _, entry := range entries {
+			err := removeTree(filepath.Join(path, entry.Name()), opts, t, errs)
+			if err == errSkipped {
+				failed = true
+			} else if err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+		if failed {
+			return errSkipped
+		}
+	}
+	if !opts.DryRun {
+		if err := os.Remove(path); err != nil {
+			return errs.record(path, err)
+		}
+	}
+	if opts.OnRemove != nil {
+		opts.OnRemove(path)
+	}
+	return t.addFile()
+}
+
 // MoveDir moves a directory from src to dst.
 // Returns an error if src doesn't exist, is not a directory, or if dst cannot be created.
 func MoveDir(src, dst string) error {
@@ -139,33 +235,238 @@ func MoveDir(src, dst string) error {
 
 // CopyDir copies a directory recursively from src to dst.
 // It preserves the file permissions and copies all contents.
+// See CopyDirWithOptions for hardlink preservation and copy statistics.
 func CopyDir(src string, dst string) error {
-	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+	_, err := CopyDirWithOptions(src, dst, CopyOptions{})
+	return err
+}
+
+// CopyDirWithOptions copies a directory recursively from src to dst.
+// Files are copied with CopyFileWithOptions; with PreserveHardlinks set, paths
+// sharing an inode in src are linked together again in dst.
+func CopyDirWithOptions(src, dst string, opts CopyOptions) (CopyDirResult, error) {
+	return CopyDirContext(context.Background(), src, dst, opts)
+}
+
+// CopyDirContext is like CopyDirWithOptions but stops with ctx.Err() once ctx
+// is cancelled and reports progress to opts.Progress. With opts.PreScan set the
+// tree is measured first so that progress updates carry totals.
+//
+// The tree is walked in order and every directory is created before any of its
+// children, while regular files are copied by a pool of opts.Workers goroutines.
+// The first error stops the walk and the workers and is returned, unless
+// opts.ContinueOnError is set: then failed paths are skipped and reported
+// together as a *MultiError once the rest of the tree has been copied.
+func CopyDirContext(ctx context.Context, src, dst string, opts CopyOptions) (CopyDirResult, error) {
+	var result CopyDirResult
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	t := newTracker(ctx, opts.Progress)
+	t.limit, t.quota = opts.RateLimit, opts.Quota
+	if opts.PreScan {
+		entries, bytes, err := scanTree(ctx, src, opts.Ignore, opts.IgnoreFile)
 		if err != nil {
+			return result, err
+		}
+		if opts.Quota > 0 && Size(bytes) > opts.Quota {
+			return result, &os.PathError{Op: "copy", Path: src, Err: ErrQuotaExceeded}
+		}
+		t.progress.FilesTotal, t.progress.BytesTotal = entries, bytes
+	}
+
+	errs := newErrorList("copy", opts.ContinueOnError)
+	var mu sync.Mutex
+	var firstErr error
+	fail := func(err error) {
+		mu.Lock()
+		if firstErr == nil {
+			firstErr = err
+			cancel()
+		}
+		mu.Unlock()
+	}
+
+	type copyJob struct{ src, dst string }
+	jobs := make(chan copyJob)
+	var wg sync.WaitGroup
+	for i := 0; i < copyWorkers(opts); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				copied, err := copyFile(job.src, job.dst, opts, t)
+				if err != nil && ctx.Err() == nil {
+					err = errs.record(job.src, err)
+				}
+				mu.Lock()
+				result.Bytes += copied.Bytes
+				switch err {
+				case nil:
+					result.Files++
+				case errSkipped:
+					result.Failed++
+				}
+				mu.Unlock()
+				if err != nil && err != errSkipped && !errs.skipVanished(job.src, err) {
+					fail(err)
+				}
+			}
+		}()
+	}
+
+	// Later paths of a hardlinked inode are linked once every copy is done,
+	// so the first path is guaranteed to exist in dst by then.
+	type inode struct{ dev, ino uint64 }
+	type pendingLink struct {
+		src, first, dst string
+		size            int64
+	}
+	firsts := make(map[inode]string)
+	var links []pendingLink
+
+	ign := newIgnoreWalk(opts.Ignore, opts.IgnoreFile)
+	walkErr := filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if errs.skipVanished(path, err) && path != src {
+				return nil
+			}
+			return skipFailed(errs.record(path, err), &mu, &result.Failed)
+		}
+		ignored, err := ign.visit(src, path, info.IsDir())
+		if err != nil {
+			if skipFailed(errs.record(path, err), &mu, &result.Failed) == nil {
+				return filepath.SkipDir
+			}
 			return err
 		}
+		if ignored {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
 		relPath, err := filepath.Rel(src, path)
 		if err != nil {
 			return err
 		}
 		targetPath := filepath.Join(dst, relPath)
 		if info.IsDir() {
-			return os.MkdirAll(targetPath, info.Mode())
-		} else {
-			srcFile, err := os.Open(path)
-			if err != nil {
+			if err := t.start(path); err != nil {
 				return err
 			}
-			defer srcFile.Close()
-
-			dstFile, err := os.Create(targetPath)
-			if err != nil {
+			if err := os.MkdirAll(targetPath, info.Mode()); err != nil {
+				if skipFailed(errs.record(path, err), &mu, &result.Failed) == nil {
+					return filepath.SkipDir
+				}
 				return err
 			}
-			defer dstFile.Close()
+			mu.Lock()
+			result.Dirs++
+			mu.Unlock()
+			return t.addFile()
+		}
 
-			_, err = io.Copy(dstFile, srcFile)
-			return err
+		if opts.move && info.Mode()&os.ModeSymlink != 0 {
+			err := copySymlink(path, targetPath, t)
+			if err == nil {
+				mu.Lock()
+				result.Files++
+				mu.Unlock()
+				return nil
+			}
+			if errs.skipVanished(path, err) {
+				return nil
+			}
+			return skipFailed(errs.record(path, err), &mu, &result.Failed)
+		}
+
+		if opts.PreserveHardlinks {
+			dev, ino, ok := fileDevIno(info)
+			nlink, _ := fileLinkCount(info)
+			if ok && nlink > 1 {
+				key := inode{dev, ino}
+				if first, seen := firsts[key]; seen {
+					links = append(links, pendingLink{path, first, targetPath, info.Size()})
+					return nil
+				}
+				firsts[key] = targetPath
+			}
+		}
+
+		select {
+		case jobs <- copyJob{path, targetPath}:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
 		}
 	})
+	close(jobs)
+	wg.Wait()
+	if walkErr != nil {
+		fail(walkErr)
+	}
+	if firstErr != nil {
+		return result, firstErr
+	}
+
+	for _, link := range links {
+		os.Remove(link.dst)
+		if err := os.Link(link.first, link.dst); err != nil {
+			// The destination cannot hold hardlinks; fall back to a copy.
+			copied, err := copyFile(link.src, link.dst, opts, t)
+			result.Bytes += copied.Bytes
+			if err != nil && ctx.Err() == nil {
+				err = errs.record(link.src, err)
+			}
+			if err == errSkipped {
+				result.Failed++
+				continue
+			}
+			if errs.skipVanished(link.src, err) {
+				continue
+			}
+			if err != nil {
+				return result, err
+			}
+			result.Files++
+			continue
+		}
+		result.Hardlinks++
+		if err := t.skipBytes(link.size); err != nil {
+			return result, err
+		}
+		if err := t.addFile(); err != nil {
+			return result, err
+		}
+	}
+	return result, errs.err(result.Files + result.Dirs + result.Hardlinks)
+}
+
+// skipFailed turns errSkipped into nil after counting it in *failed, so a
+// walk carries on past a failure that has already been recorded.
+func skipFailed(err error, mu *sync.Mutex, failed *int) error {
+	if err != errSkipped {
+		return err
+	}
+	mu.Lock()
+	*failed++
+	mu.Unlock()
+	return nil
+}
+
+// copyWorkers returns the number of concurrent file copies allowed by opts.
+// Each copy holds two file descriptors open.
+func copyWorkers(opts CopyOptions) int {
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	if opts.MaxOpenFiles > 0 && workers > opts.MaxOpenFiles/2 {
+		workers = opts.MaxOpenFiles / 2
+	}
+	if workers < 1 {
+		workers = 1
+	}
+	return workers
 }
@@ -0,0 +1,119 @@
+// Package fsutils provides filesystem utility functions for Go applications.
+package fsutils
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// OpError records a failure of one path during a recursive operation.
+type OpError struct {
+	Op   string // Operation that failed, such as "copy" or "remove"
+	Path string // Path the operation failed on
+	Err  error  // Underlying cause
+}
+
+// Error returns the operation, path and cause as a single line.
+func (e *OpError) Error() string {
+	return e.Op + " " + e.Path + ": " + e.Err.Error()
+}
+
+// Unwrap returns the underlying cause.
+func (e *OpError) Unwrap() error {
+	return e.Err
+}
+
+// MultiError is returned by recursive operations run with ContinueOnError
+// when one or more paths failed. The operation carried on past each failure.
+type MultiError struct {
+	Errors    []*OpError // Failures in the order they happened
+	Succeeded int        // Number of entries processed successfully
+}
+
+// Error summarises the failures and quotes the first one.
+func (e *MultiError) Error() string {
+	if len(e.Errors) == 1 {
+		return fmt.Sprintf("1 error (%d succeeded): %v", e.Succeeded, e.Errors[0])
+	}
+	return fmt.Sprintf("%d errors (%d succeeded), first: %v", len(e.Errors), e.Succeeded, e.Errors[0])
+}
+
+// Unwrap returns every failure, so errors.Is and errors.As look through them.
+func (e *MultiError) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i, err := range e.Errors {
+		errs[i] = err
+	}
+	return errs
+}
+
+// errSkipped tells a recursive operation that a failure has been recorded and
+// it should skip the affected path and carry on.
+var errSkipped = errors.New("skipped after error")
+
+// errorList collects failures for ContinueOnError. A nil *errorList means the
+// operation stops at the first failure.
+type errorList struct {
+	op   string
+	mu   sync.Mutex
+	errs []*OpError
+}
+
+// newErrorList returns a collector for op, or nil when continueOnError is false.
+func newErrorList(op string, continueOnError bool) *errorList {
+	if !continueOnError {
+		return nil
+	}
+	return &errorList{op: op}
+}
+
+// record handles the failure err of path. When collecting, it stores the
+// failure and returns errSkipped; otherwise it returns err unchanged.
+// Paths that vanished in the meantime are not failures: their errors come
+// back unchanged, and callers check skipVanished to skip them.
+func (l *errorList) record(path string, err error) error {
+	if l == nil || err == nil || vanished(path, err) {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.errs = append(l.errs, &OpError{Op: l.op, Path: path, Err: err})
+	return errSkipped
+}
+
+// vanished reports whether err comes from path having been removed since it
+// was listed. A dangling symlink at path does not count.
+func vanished(path string, err error) bool {
+	if !os.IsNotExist(err) {
+		return false
+	}
+	_, statErr := os.Lstat(path)
+	return os.IsNotExist(statErr)
+}
+
+// skipVanished reports whether the caller should skip path, because failures
+// are being collected and path vanished since it was listed. Without
+// ContinueOnError the error is returned as usual.
+func (l *errorList) skipVanished(path string, err error) bool {
+	return l != nil && vanished(path, err)
+}
+
+// count returns the number of recorded failures.
+func (l *errorList) count() int {
+	if l == nil {
+		return 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.errs)
+}
+
+// err returns the collected failures as a *MultiError, or nil if there were none.
+func (l *errorList) err(succeeded int) error {
+	if l.count() == 0 {
+		return nil
+	}
+	return &MultiError{Errors: l.errs, Succeeded: succeeded}
+}
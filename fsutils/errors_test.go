@@ -0,0 +1,141 @@
+package fsutils_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/utsav-56/go_fs_utils/fsutils"
+)
+
+func TestContinueOnError(t *testing.T) {
+	// Create a temporary directory for our tests
+	tempDir, err := os.MkdirTemp("", "fsutils-errors-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	src := filepath.Join(tempDir, "src")
+	if err := fsutils.Mkdir(src); err != nil {
+		t.Fatalf("Failed to create test directory: %v", err)
+	}
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		if err := os.WriteFile(filepath.Join(src, name), []byte(name), 0644); err != nil {
+			t.Fatalf("Failed to write test file: %v", err)
+		}
+	}
+
+	// Block one destination file with a directory of the same name
+	dst := filepath.Join(tempDir, "dst")
+	if err := fsutils.Mkdir(filepath.Join(dst, "b.txt")); err != nil {
+		t.Fatalf("Failed to create test directory: %v", err)
+	}
+
+	// Test the default mode stops with the plain error
+	_, err = fsutils.CopyDirWithOptions(src, dst, fsutils.CopyOptions{Workers: 1})
+	var multi *fsutils.MultiError
+	if err == nil || errors.As(err, &multi) {
+		t.Fatalf("CopyDirWithOptions returned %v, want a single error", err)
+	}
+
+	// Test ContinueOnError copies the rest and reports the failure
+	result, err := fsutils.CopyDirWithOptions(src, dst, fsutils.CopyOptions{ContinueOnError: true})
+	if !errors.As(err, &multi) {
+		t.Fatalf("CopyDirWithOptions returned %v, want a *MultiError", err)
+	}
+	if len(multi.Errors) != 1 || multi.Errors[0].Op != "copy" || multi.Errors[0].Path != filepath.Join(src, "b.txt") {
+		t.Errorf("MultiError holds wrong failures: %v", multi)
+	}
+	if !errors.Is(err, syscall.EISDIR) {
+		t.Errorf("MultiError doesn't unwrap to the cause: %v", err)
+	}
+	if result.Files != 2 || result.Failed != 1 {
+		t.Errorf("CopyDirWithOptions returned wrong statistics: %+v", result)
+	}
+	if !fsutils.FileExists(filepath.Join(dst, "a.txt")) || !fsutils.FileExists(filepath.Join(dst, "c.txt")) {
+		t.Errorf("CopyDirWithOptions stopped at the first failure")
+	}
+
+	// Test HashDir
+	sums, err := fsutils.HashDir(context.Background(), src, fsutils.HashOptions{ContinueOnError: true})
+	if err != nil {
+		t.Fatalf("HashDir failed: %v", err)
+	}
+	if len(sums) != 3 || sums["a.txt"] == "" || sums["a.txt"] == sums["b.txt"] {
+		t.Errorf("HashDir returned wrong checksums: %v", sums)
+	}
+
+	// Test RmDirContext with ContinueOnError on a removable tree
+	if err := fsutils.RmDirContext(context.Background(), dst, fsutils.RemoveOptions{ContinueOnError: true}); err != nil {
+		t.Errorf("RmDirContext failed: %v", err)
+	}
+	if fsutils.DirExists(dst) {
+		t.Errorf("RmDirContext left %s behind", dst)
+	}
+}
+
+func TestContinueOnErrorVanishedPaths(t *testing.T) {
+	tempDir := t.TempDir()
+	src := filepath.Join(tempDir, "src")
+	if err := fsutils.Mkdir(src); err != nil {
+		t.Fatalf("Failed to create test directory: %v", err)
+	}
+	for _, name := range []string{"a.txt", "b.txt"} {
+		if err := os.WriteFile(filepath.Join(src, name), []byte(name), 0644); err != nil {
+			t.Fatalf("Failed to write test file: %v", err)
+		}
+	}
+	if err := os.Symlink("missing", filepath.Join(src, "dangling")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+
+	// Remove b.txt after it was listed but before it is opened
+	vanish := func(p fsutils.Progress) {
+		if filepath.Base(p.Path) == "b.txt" {
+			os.Remove(p.Path)
+		}
+	}
+
+	// Test a vanished file is skipped silently, and a dangling symlink is a failure
+	result, err := fsutils.CopyDirWithOptions(src, filepath.Join(tempDir, "dst"), fsutils.CopyOptions{ContinueOnError: true, Workers: 1, Progress: vanish})
+	var multi *fsutils.MultiError
+	if !errors.As(err, &multi) {
+		t.Fatalf("CopyDirWithOptions returned %v, want a *MultiError", err)
+	}
+	if len(multi.Errors) != 1 || multi.Errors[0].Path != filepath.Join(src, "dangling") {
+		t.Errorf("MultiError holds wrong failures: %v", multi)
+	}
+	if result.Files != 1 || result.Failed != 1 {
+		t.Errorf("CopyDirWithOptions returned wrong statistics: %+v", result)
+	}
+
+	// Test HashDir skips a vanished file
+	if err := os.WriteFile(filepath.Join(src, "b.txt"), []byte("b.txt"), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	sums, err := fsutils.HashDir(context.Background(), src, fsutils.HashOptions{ContinueOnError: true, Progress: vanish})
+	if err != nil {
+		t.Fatalf("HashDir failed: %v", err)
+	}
+	if len(sums) != 1 || sums["a.txt"] == "" {
+		t.Errorf("HashDir returned wrong checksums: %v", sums)
+	}
+
+	// Test the default mode still fails on a vanished file
+	if err := os.WriteFile(filepath.Join(src, "b.txt"), []byte("b.txt"), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	if _, err := fsutils.HashDir(context.Background(), src, fsutils.HashOptions{Progress: vanish}); !os.IsNotExist(err) {
+		t.Errorf("HashDir of a vanished file returned %v, want a not-exist error", err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "b.txt"), []byte("b.txt"), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	if _, err := fsutils.CopyDirWithOptions(src, filepath.Join(tempDir, "strict"), fsutils.CopyOptions{Workers: 1, Progress: vanish}); !os.IsNotExist(err) {
+		t.Errorf("CopyDirWithOptions of a vanished file returned %v, want a not-exist error", err)
+	}
+}
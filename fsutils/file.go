@@ -2,8 +2,8 @@
 package fsutils
 
 import (
+	"context"
 	"fmt"
-	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -57,9 +57,10 @@ func GetFileInfo(path string) map[string]interface{} {
 		"dateModified": info.ModTime(),
 		"isExecutable": isExecutable,
 		"size":         info.Size(),
-		"sizeKB":       float64(info.Size()) / 1024,
-		"sizeMB":       float64(info.Size()) / (1024 * 1024),
-		"sizeGB":       float64(info.Size()) / (1024 * 1024 * 1024),
+		"sizeKB":       Size(info.Size()).In(KiB),
+		"sizeMB":       Size(info.Size()).In(MiB),
+		"sizeGB":       Size(info.Size()).In(GiB),
+		"sizeHuman":    Size(info.Size()).String(),
 		"permissions":  info.Mode().Perm().String(),
 		"isHidden":     strings.HasPrefix(info.Name(), "."),
 		"mode":         info.Mode().String(),
@@ -91,28 +92,9 @@ func MoveFile(src, dst string) error {
 
 // CopyFile copies a file from src to dst.
 // Returns an error if src doesn't exist, is a directory, or if dst cannot be created.
+// See CopyFileWithOptions for control over the copy mechanism.
 func CopyFile(src, dst string) error {
-	srcFile, err := os.Open(src)
-	if err != nil {
-		return err
-	}
-	defer srcFile.Close()
-
-	info, err := srcFile.Stat()
-	if err != nil {
-		return err
-	}
-	if info.IsDir() {
-		return fmt.Errorf("'%s' is a directory, use CopyDir or Cp", src)
-	}
-
-	dstFile, err := os.Create(dst)
-	if err != nil {
-		return err
-	}
-	defer dstFile.Close()
-
-	_, err = io.Copy(dstFile, srcFile)
+	_, err := CopyFileWithOptions(src, dst, CopyOptions{})
 	return err
 }
 
@@ -123,14 +105,14 @@ func Symlink(target string, linkName string) error {
 }
 
 // Cp is a convenience function that copies either a file or directory from src to dst.
-// It automatically determines whether to use CopyFile or CopyDir based on the src path.
+// It automatically determines whether to use CopyFile or CopyDir based on the src path,
+// and follows cp(1) for the destination: if dst is an existing directory, src is copied
+// into it, and a directory src ending in a separator has its contents copied instead.
+// A src that does not exist but contains pattern syntax is expanded with Glob, and
+// every match is copied below dst at the path it has below the pattern's literal
+// prefix, so Cp("assets/**/*.png", "out") copies "assets/icons/a.png" to "out/icons/a.png".
+// See CopyPaths for the details and for copying several sources.
 func Cp(src, dst string) error {
-	info, err := os.Stat(src)
-	if err != nil {
-		return err
-	}
-	if info.IsDir() {
-		return CopyDir(src, dst)
-	}
-	return CopyFile(src, dst)
+	_, err := CopyPaths(context.Background(), []string{src}, dst, CopyOptions{})
+	return err
 }
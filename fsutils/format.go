@@ -0,0 +1,215 @@
+// Package fsutils provides filesystem utility functions for Go applications.
+package fsutils
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Format selects how a RecordWriter serialises records.
+type Format int
+
+const (
+	// FormatTable writes aligned columns with sizes in binary units.
+	FormatTable Format = iota
+	// FormatJSON writes a single JSON array.
+	FormatJSON
+	// FormatNDJSON writes one JSON object per line.
+	FormatNDJSON
+	// FormatCSV writes comma-separated values with a header row.
+	FormatCSV
+)
+
+// String returns the name of the format, as accepted by ParseFormat.
+func (f Format) String() string {
+	switch f {
+	case FormatJSON:
+		return "json"
+	case FormatNDJSON:
+		return "ndjson"
+	case FormatCSV:
+		return "csv"
+	default:
+		return "table"
+	}
+}
+
+// ParseFormat returns the format called name: "table", "json", "ndjson" or "csv".
+func ParseFormat(name string) (Format, error) {
+	for _, f := range []Format{FormatTable, FormatJSON, FormatNDJSON, FormatCSV} {
+		if strings.EqualFold(name, f.String()) {
+			return f, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown format %q", name)
+}
+
+// FileRecord is the schema used to export path information, directory
+// listings and walk results.
+type FileRecord struct {
+	Path    string    `json:"path"`
+	Name    string    `json:"name"`
+	Type    string    `json:"type"` // "file", "dir", "symlink" or "other"
+	Size    int64     `json:"size"`
+	Mode    string    `json:"mode"` // Type and permissions in ls notation
+	ModTime time.Time `json:"dateModified"`
+	Target  string    `json:"target,omitempty"` // Link target, for symlinks
+}
+
+// NewFileRecord describes the entry at path from its info, such as a
+// WalkEntry's Info.
+func NewFileRecord(path string, info fs.FileInfo) FileRecord {
+	rec := FileRecord{
+		Path:    path,
+		Name:    info.Name(),
+		Type:    typeName(info.Mode()),
+		Size:    info.Size(),
+		Mode:    info.Mode().String(),
+		ModTime: info.ModTime(),
+	}
+	if info.Mode()&fs.ModeSymlink != 0 {
+		rec.Target, _ = os.Readlink(path)
+	}
+	return rec
+}
+
+// typeName names the kind of entry described by mode, as used in FileRecord.Type.
+func typeName(mode fs.FileMode) string {
+	switch {
+	case mode.IsDir():
+		return "dir"
+	case mode.IsRegular():
+		return "file"
+	case mode&fs.ModeSymlink != 0:
+		return "symlink"
+	default:
+		return "other"
+	}
+}
+
+// StatRecord describes the entry at path without following a final symlink.
+func StatRecord(path string) (FileRecord, error) {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return FileRecord{}, err
+	}
+	return NewFileRecord(path, info), nil
+}
+
+// recordHeader names the CSV columns.
+var recordHeader = []string{"path", "name", "type", "size", "mode", "dateModified", "target"}
+
+// RecordWriter serialises FileRecords to an io.Writer in one Format.
+// JSON, NDJSON and CSV output is streamed as records are written; a table
+// is buffered to align its columns. Flush must be called after the last record.
+type RecordWriter struct {
+	w      io.Writer
+	format Format
+	csv    *csv.Writer
+	rows   []FileRecord
+	count  int
+}
+
+// NewRecordWriter returns a RecordWriter writing to w in format.
+func NewRecordWriter(w io.Writer, format Format) *RecordWriter {
+	rw := &RecordWriter{w: w, format: format}
+	if format == FormatCSV {
+		rw.csv = csv.NewWriter(w)
+	}
+	return rw
+}
+
+// Write serialises rec.
+func (rw *RecordWriter) Write(rec FileRecord) error {
+	defer func() { rw.count++ }()
+	switch rw.format {
+	case FormatJSON, FormatNDJSON:
+		data, err := json.Marshal(rec)
+		if err != nil {
+			return err
+		}
+		prefix, suffix := "", "\n"
+		if rw.format == FormatJSON {
+			prefix, suffix = ",\n  ", ""
+			if rw.count == 0 {
+				prefix = "[\n  "
+			}
+		}
+		_, err = io.WriteString(rw.w, prefix+string(data)+suffix)
+		return err
+	case FormatCSV:
+		if rw.count == 0 {
+			if err := rw.csv.Write(recordHeader); err != nil {
+				return err
+			}
+		}
+		return rw.csv.Write([]string{
+			rec.Path, rec.Name, rec.Type, strconv.FormatInt(rec.Size, 10),
+			rec.Mode, rec.ModTime.Format(time.RFC3339Nano), rec.Target,
+		})
+	default:
+		rw.rows = append(rw.rows, rec)
+		return nil
+	}
+}
+
+// Flush completes the output: it closes a JSON array, writes a CSV header
+// if no record was written, and renders a table.
+func (rw *RecordWriter) Flush() error {
+	switch rw.format {
+	case FormatJSON:
+		end := "\n]\n"
+		if rw.count == 0 {
+			end = "[]\n"
+		}
+		_, err := io.WriteString(rw.w, end)
+		return err
+	case FormatNDJSON:
+		return nil
+	case FormatCSV:
+		if rw.count == 0 {
+			rw.csv.Write(recordHeader)
+		}
+		rw.csv.Flush()
+		return rw.csv.Error()
+	default:
+		err := rw.writeTable()
+		rw.rows = nil
+		return err
+	}
+}
+
+// writeTable renders the buffered rows as aligned columns, with sizes
+// right-aligned.
+func (rw *RecordWriter) writeTable() error {
+	cells := [][]string{{"MODE", "SIZE", "MODIFIED", "PATH"}}
+	for _, rec := range rw.rows {
+		path := rec.Path
+		if rec.Target != "" {
+			path += " -> " + rec.Target
+		}
+		cells = append(cells, []string{rec.Mode, Size(rec.Size).String(), rec.ModTime.Format("2006-01-02 15:04"), path})
+	}
+	widths := make([]int, len(cells[0]))
+	for _, row := range cells {
+		for i, cell := range row {
+			if len(cell) > widths[i] {
+				widths[i] = len(cell)
+			}
+		}
+	}
+	for _, row := range cells {
+		line := fmt.Sprintf("%-*s  %*s  %-*s  %s", widths[0], row[0], widths[1], row[1], widths[2], row[2], row[3])
+		if _, err := io.WriteString(rw.w, line+"\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
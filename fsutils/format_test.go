@@ -0,0 +1,95 @@
+package fsutils_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/utsav-56/go_fs_utils/fsutils"
+)
+
+func TestRecordWriter(t *testing.T) {
+	// Create a temporary directory for our tests
+	tempDir, err := os.MkdirTemp("", "fsutils-format-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	file := filepath.Join(tempDir, "big.bin")
+	os.WriteFile(file, make([]byte, 1536), 0644)
+	link := filepath.Join(tempDir, "link")
+	if err := os.Symlink("big.bin", link); err != nil {
+		t.Fatalf("Failed to create symlink: %v", err)
+	}
+	var records []fsutils.FileRecord
+	for _, path := range []string{tempDir, file, link} {
+		rec, err := fsutils.StatRecord(path)
+		if err != nil {
+			t.Fatalf("StatRecord failed: %v", err)
+		}
+		records = append(records, rec)
+	}
+	if records[0].Type != "dir" || records[1].Type != "file" || records[2].Type != "symlink" || records[2].Target != "big.bin" {
+		t.Fatalf("Unexpected records: %+v", records)
+	}
+
+	write := func(format fsutils.Format, records []fsutils.FileRecord) string {
+		t.Helper()
+		var buf bytes.Buffer
+		rw := fsutils.NewRecordWriter(&buf, format)
+		for _, rec := range records {
+			if err := rw.Write(rec); err != nil {
+				t.Fatalf("Write failed: %v", err)
+			}
+		}
+		if err := rw.Flush(); err != nil {
+			t.Fatalf("Flush failed: %v", err)
+		}
+		return buf.String()
+	}
+
+	// Test JSON, including an empty listing
+	var decoded []fsutils.FileRecord
+	if err := json.Unmarshal([]byte(write(fsutils.FormatJSON, records)), &decoded); err != nil || len(decoded) != 3 {
+		t.Errorf("JSON output didn't decode to 3 records: %v", err)
+	} else if decoded[1].Size != 1536 || !decoded[1].ModTime.Equal(records[1].ModTime) {
+		t.Errorf("JSON round trip changed the record: %+v", decoded[1])
+	}
+	if out := write(fsutils.FormatJSON, nil); out != "[]\n" {
+		t.Errorf("Empty JSON output = %q", out)
+	}
+
+	// Test NDJSON
+	lines := strings.Split(strings.TrimSpace(write(fsutils.FormatNDJSON, records)), "\n")
+	if len(lines) != 3 {
+		t.Errorf("NDJSON output has %d lines, want 3", len(lines))
+	}
+
+	// Test CSV
+	rows, err := csv.NewReader(strings.NewReader(write(fsutils.FormatCSV, records))).ReadAll()
+	if err != nil || len(rows) != 4 || rows[0][0] != "path" || rows[2][3] != "1536" {
+		t.Errorf("Unexpected CSV rows %v: %v", rows, err)
+	}
+
+	// Test the table aligns human-friendly sizes
+	table := write(fsutils.FormatTable, records[1:])
+	want := "MODE           SIZE  MODIFIED          PATH\n"
+	if !strings.HasPrefix(table, want) || !strings.Contains(table, "  1.5 KiB  ") || !strings.Contains(table, "      7 B  ") || !strings.Contains(table, link+" -> big.bin") {
+		t.Errorf("Unexpected table:\n%s", table)
+	}
+
+	// Test format names
+	for _, name := range []string{"table", "json", "ndjson", "csv"} {
+		if f, err := fsutils.ParseFormat(name); err != nil || f.String() != name {
+			t.Errorf("ParseFormat(%q) = %v, %v", name, f, err)
+		}
+	}
+	if _, err := fsutils.ParseFormat("xml"); err == nil {
+		t.Error("ParseFormat accepted an unknown format")
+	}
+}
@@ -0,0 +1,314 @@
+// Package fsutils provides filesystem utility functions for Go applications.
+package fsutils
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Match reports whether name, a slash-separated path, matches pattern.
+// Patterns extend those of path.Match:
+//
+//   - "**" as a whole segment matches any number of segments, including none;
+//   - "{a,b,c}" matches any of the comma-separated alternatives, which may
+//     themselves contain patterns and braces;
+//   - "[!...]" is a negated character class, like "[^...]";
+//   - a leading "!" negates the whole pattern.
+//
+// The only possible error is path.ErrBadPattern, for a malformed pattern.
+func Match(pattern, name string) (bool, error) {
+	negate := strings.HasPrefix(pattern, "!")
+	g, err := compileGlob(strings.TrimPrefix(pattern, "!"))
+	if err != nil {
+		return false, err
+	}
+	return g.match(name) != negate, nil
+}
+
+// Glob returns the paths matching any of patterns, in the syntax of Match,
+// leaving out those that match a pattern starting with "!":
+//
+//	Glob("assets/**/*.{png,jpg}", "!assets/vendor/**")
+//
+// The results are sorted depth-first, as Walk visits them, and contain no
+// duplicates. Directories are searched without following symlinks, and I/O
+// errors such as unreadable directories are ignored, as filepath.Glob does.
+func Glob(patterns ...string) ([]string, error) {
+	var include, exclude []globPattern
+	for _, pattern := range patterns {
+		negate := strings.HasPrefix(pattern, "!")
+		g, err := compileGlob(strings.TrimPrefix(pattern, "!"))
+		if err != nil {
+			return nil, err
+		}
+		if negate {
+			exclude = append(exclude, g)
+		} else {
+			include = append(include, g)
+		}
+	}
+
+	seen := make(map[string]bool)
+	var matches []string
+	add := func(p string) {
+		slashed := filepath.ToSlash(p)
+		if seen[slashed] {
+			return
+		}
+		seen[slashed] = true
+		for _, g := range exclude {
+			if g.match(slashed) {
+				return
+			}
+		}
+		matches = append(matches, p)
+	}
+	for _, g := range include {
+		for _, segments := range g {
+			dir := "."
+			if segments[0] == "" && len(segments) > 1 {
+				dir, segments = "/", segments[1:]
+			}
+			expandGlob(dir, segments, add)
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return pathLess(filepath.ToSlash(matches[i]), filepath.ToSlash(matches[j]))
+	})
+	return matches, nil
+}
+
+// globPattern is a compiled pattern: its brace alternatives, each split into
+// segments that path.Match understands.
+type globPattern [][]string
+
+// compileGlob expands the braces of pattern and checks every segment.
+func compileGlob(pattern string) (globPattern, error) {
+	alternatives, err := expandBraces(pattern)
+	if err != nil {
+		return nil, err
+	}
+	g := make(globPattern, 0, len(alternatives))
+	for _, alt := range alternatives {
+		segments := strings.Split(alt, "/")
+		for i, segment := range segments {
+			segments[i] = convertClasses(segment)
+			if _, err := path.Match(segments[i], ""); err != nil {
+				return nil, path.ErrBadPattern
+			}
+		}
+		g = append(g, segments)
+	}
+	return g, nil
+}
+
+// match reports whether the slash-separated name matches any alternative.
+func (g globPattern) match(name string) bool {
+	parts := strings.Split(name, "/")
+	for _, segments := range g {
+		if matchSegments(segments, parts) {
+			return true
+		}
+	}
+	return false
+}
+
+// expandBraces returns the alternatives of pattern, with every "{a,b}"
+// replaced by each of its options in turn.
+func expandBraces(pattern string) ([]string, error) {
+	open, depth := -1, 0
+	var commas []int
+	inClass := false
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case c == '\\':
+			i++
+		case inClass:
+			inClass = c != ']'
+		case c == '[':
+			inClass = true
+		case c == '{':
+			if depth == 0 {
+				open = i
+			}
+			depth++
+		case c == ',' && depth == 1:
+			commas = append(commas, i)
+		case c == '}' && depth > 0:
+			if depth--; depth > 0 {
+				continue
+			}
+			bounds := append(append([]int{open}, commas...), i)
+			var out []string
+			for j := 0; j+1 < len(bounds); j++ {
+				expanded, err := expandBraces(pattern[:open] + pattern[bounds[j]+1:bounds[j+1]] + pattern[i+1:])
+				if err != nil {
+					return nil, err
+				}
+				out = append(out, expanded...)
+			}
+			return out, nil
+		}
+	}
+	if depth > 0 {
+		return nil, path.ErrBadPattern
+	}
+	return []string{pattern}, nil
+}
+
+// convertClasses rewrites the negated character classes of a segment from
+// the "[!...]" form of shells to the "[^...]" form of path.Match.
+func convertClasses(segment string) string {
+	if !strings.Contains(segment, "[!") {
+		return segment
+	}
+	b := []byte(segment)
+	for i := 0; i < len(b); i++ {
+		switch b[i] {
+		case '\\':
+			i++
+		case '[':
+			if i+1 < len(b) && b[i+1] == '!' {
+				b[i+1] = '^'
+			}
+		}
+	}
+	return string(b)
+}
+
+// matchSegments matches pattern segments against path segments.
+func matchSegments(pattern, parts []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for len(pattern) > 1 && pattern[1] == "**" {
+				pattern = pattern[1:]
+			}
+			for i := 0; i <= len(parts); i++ {
+				if matchSegments(pattern[1:], parts[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(parts) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], parts[0]); !ok {
+			return false
+		}
+		pattern, parts = pattern[1:], parts[1:]
+	}
+	return len(parts) == 0
+}
+
+// expandGlob calls add with every path below dir matching the pattern
+// segments.
+func expandGlob(dir string, segments []string, add func(string)) {
+	if len(segments) == 0 {
+		add(dir)
+		return
+	}
+	segment, rest := segments[0], segments[1:]
+	switch {
+	case segment == "**" && len(rest) == 0:
+		add(dir)
+		filepath.WalkDir(dir, func(p string, d os.DirEntry, err error) error {
+			if err == nil && p != dir {
+				add(p)
+			}
+			return nil
+		})
+	case segment == "**":
+		filepath.WalkDir(dir, func(p string, d os.DirEntry, err error) error {
+			if err == nil && d.IsDir() {
+				expandGlob(p, rest, add)
+			}
+			return nil
+		})
+	case !strings.ContainsAny(segment, `*?[\`):
+		child := filepath.Join(dir, segment)
+		if _, err := os.Lstat(child); err == nil {
+			expandGlob(child, rest, add)
+		}
+	default:
+		entries, _ := os.ReadDir(dir)
+		for _, entry := range entries {
+			if ok, _ := path.Match(segment, entry.Name()); ok {
+				expandGlob(filepath.Join(dir, entry.Name()), rest, add)
+			}
+		}
+	}
+}
+
+// globBase returns the directory made of the leading segments of pattern
+// that contain no pattern syntax, "." if there are none.
+func globBase(pattern string) string {
+	segments := strings.Split(pattern, "/")
+	n := 0
+	for n < len(segments)-1 && !strings.ContainsAny(segments[n], `*?[{\`) {
+		n++
+	}
+	base := strings.Join(segments[:n], "/")
+	if base == "" && strings.HasPrefix(pattern, "/") {
+		base = "/"
+	}
+	if base == "" {
+		base = "."
+	}
+	return filepath.FromSlash(base)
+}
+
+// isGlob reports whether src should be expanded as a pattern: it contains
+// pattern syntax and does not name an existing path.
+func isGlob(src string) bool {
+	if !strings.ContainsAny(src, "*?[{") {
+		return false
+	}
+	_, err := os.Lstat(src)
+	return os.IsNotExist(err)
+}
+
+// forGlob calls op for every path matching pattern, with the path below dst
+// that the match has below the pattern's base directory, so
+// "assets/**/*.png" copied to "out" puts "assets/icons/a.png" at
+// "out/icons/a.png". Matches inside an earlier matched directory are left to
+// op, which handles the whole directory. Like a plain source, a match that
+// would be copied or moved onto itself or into its own tree is refused, as
+// checkTarget says for the operation verb. It fails if nothing matches, and
+// carries on past failures op has recorded and reports as errSkipped.
+func forGlob(verb, pattern, dst string, op func(src, dst string) error) error {
+	matches, err := Glob(filepath.ToSlash(pattern))
+	if err != nil {
+		return &os.PathError{Op: "glob", Path: pattern, Err: err}
+	}
+	if len(matches) == 0 {
+		return &os.PathError{Op: "glob", Path: pattern, Err: os.ErrNotExist}
+	}
+	base := globBase(filepath.ToSlash(pattern))
+	done := ""
+	for _, match := range matches {
+		if done == "." || done != "" && strings.HasPrefix(match, done+string(filepath.Separator)) {
+			continue
+		}
+		rel, err := filepath.Rel(base, match)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if err := checkTarget(verb, match, target); err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		if err := op(match, target); err != nil && err != errSkipped {
+			return err
+		}
+		done = match
+	}
+	return nil
+}
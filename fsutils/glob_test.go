@@ -0,0 +1,124 @@
+package fsutils_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/utsav-56/go_fs_utils/fsutils"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern, name string
+		want          bool
+	}{
+		{"*.go", "main.go", true},
+		{"*.go", "cmd/main.go", false},
+		{"**/*.go", "main.go", true},
+		{"**/*.go", "a/b/c/main.go", true},
+		{"src/**", "src", true},
+		{"src/**", "src/a/b", true},
+		{"src/**/test/*.go", "src/test/x.go", true},
+		{"src/**/test/*.go", "src/a/b/test/x.go", true},
+		{"*.{png,jpg}", "logo.jpg", true},
+		{"*.{png,jpg}", "logo.gif", false},
+		{"{a,b/{c,d}}/x", "b/d/x", true},
+		{"{a,b/{c,d}}/x", "b/x", false},
+		{"[!a-c]*", "dog", true},
+		{"[!a-c]*", "cat", false},
+		{"[^a-c]*", "cat", false},
+		{`\*.go`, "*.go", true},
+		{`\*.go`, "x.go", false},
+		{"!*.go", "main.go", false},
+		{"!*.go", "README.md", true},
+	}
+	for _, tt := range tests {
+		got, err := fsutils.Match(tt.pattern, tt.name)
+		if err != nil || got != tt.want {
+			t.Errorf("Match(%q, %q) = %v, %v; want %v", tt.pattern, tt.name, got, err, tt.want)
+		}
+	}
+	for _, pattern := range []string{"[", "{a,b", `a/b\`} {
+		if _, err := fsutils.Match(pattern, "x"); err == nil {
+			t.Errorf("Match(%q) succeeded, want an error", pattern)
+		}
+	}
+}
+
+func TestGlob(t *testing.T) {
+	// Create a temporary directory for our tests
+	tempDir, err := os.MkdirTemp("", "fsutils-glob-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	assets := filepath.Join(tempDir, "assets")
+	fsutils.Mkdir(filepath.Join(assets, "icons", "small"))
+	fsutils.Mkdir(filepath.Join(assets, "vendor"))
+	for _, name := range []string{"logo.png", "photo.jpg", "notes.txt", "icons/a.png", "icons/small/b.png", "vendor/c.png"} {
+		os.WriteFile(filepath.Join(assets, filepath.FromSlash(name)), []byte(name), 0644)
+	}
+	root := filepath.ToSlash(tempDir)
+
+	rels := func(paths []string) []string {
+		var out []string
+		for _, p := range paths {
+			rel, _ := filepath.Rel(tempDir, p)
+			out = append(out, filepath.ToSlash(rel))
+		}
+		return out
+	}
+
+	// Test recursion, braces and negation in depth-first order
+	got, err := fsutils.Glob(root+"/assets/**/*.{png,jpg}", "!"+root+"/assets/vendor/**")
+	if err != nil {
+		t.Fatalf("Glob failed: %v", err)
+	}
+	want := []string{"assets/icons/a.png", "assets/icons/small/b.png", "assets/logo.png", "assets/photo.jpg"}
+	if !reflect.DeepEqual(rels(got), want) {
+		t.Errorf("Glob returned %v, want %v", rels(got), want)
+	}
+
+	// Test overlapping patterns return each path once
+	got, err = fsutils.Glob(root+"/assets/*.png", root+"/assets/logo.*")
+	if err != nil || !reflect.DeepEqual(rels(got), []string{"assets/logo.png"}) {
+		t.Errorf("Glob with overlapping patterns returned %v, %v", rels(got), err)
+	}
+
+	// Test Cp with a pattern keeps paths below the literal prefix
+	out := filepath.Join(tempDir, "out")
+	if err := fsutils.Cp(filepath.Join(assets, "**", "*.png"), out); err != nil {
+		t.Fatalf("Cp with a pattern failed: %v", err)
+	}
+	for _, name := range []string{"logo.png", "icons/a.png", "icons/small/b.png", "vendor/c.png"} {
+		if !fsutils.FileExists(filepath.Join(out, filepath.FromSlash(name))) {
+			t.Errorf("Cp with a pattern did not copy %s", name)
+		}
+	}
+	if fsutils.FileExists(filepath.Join(out, "photo.jpg")) {
+		t.Errorf("Cp with a pattern copied a file that does not match")
+	}
+	if err := fsutils.Cp(filepath.Join(assets, "*.gif"), out); !os.IsNotExist(err) {
+		t.Errorf("Cp with a pattern matching nothing returned %v", err)
+	}
+
+	// Test Cp with a pattern refuses to copy its matches onto themselves
+	if err := fsutils.Cp(filepath.Join(assets, "*.png"), assets); err == nil {
+		t.Errorf("Cp of matches onto themselves succeeded")
+	}
+	if data, _ := os.ReadFile(filepath.Join(assets, "logo.png")); string(data) != "logo.png" {
+		t.Errorf("Cp of matches onto themselves truncated them: %q", data)
+	}
+
+	// Test Mv with a pattern
+	moved := filepath.Join(tempDir, "moved")
+	if err := fsutils.Mv(filepath.Join(assets, "*.txt"), moved); err != nil {
+		t.Fatalf("Mv with a pattern failed: %v", err)
+	}
+	if !fsutils.FileExists(filepath.Join(moved, "notes.txt")) || fsutils.FileExists(filepath.Join(assets, "notes.txt")) {
+		t.Errorf("Mv with a pattern did not move notes.txt")
+	}
+}
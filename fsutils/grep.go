@@ -0,0 +1,219 @@
+// Package fsutils provides filesystem utility functions for Go applications.
+package fsutils
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// binarySniffLen is how much of a file Grep inspects to decide whether it is
+// binary: like git, a NUL byte in the first 8000 bytes means it is.
+const binarySniffLen = 8000
+
+// GrepOptions controls the behaviour of Grep.
+type GrepOptions struct {
+	// Literal searches for the pattern as a plain string rather than a
+	// regular expression.
+	Literal bool
+	// IgnoreCase matches letters regardless of case.
+	IgnoreCase bool
+	// Before and After are the numbers of context lines reported with each
+	// matching line.
+	Before, After int
+	// MaxCount, if positive, stops searching a file after this many
+	// matching lines.
+	MaxCount int
+	// Workers is the number of files searched concurrently. Zero means one
+	// per CPU.
+	Workers int
+	// Walk selects the files that are searched: its filters and depth limit
+	// apply as they do for Walk, and entries other than regular files are
+	// ignored. With Walk.ContinueOnError, unreadable files and directories
+	// are skipped and reported together as a *MultiError alongside the
+	// matches that were found.
+	Walk WalkOptions
+}
+
+// GrepMatch is one matching line found by Grep.
+type GrepMatch struct {
+	Path   string   `json:"path"`             // Root joined with the file's relative path
+	Line   int      `json:"line"`             // 1-based line number
+	Column int      `json:"column"`           // 1-based byte offset of the first match in the line
+	Text   string   `json:"text"`             // The line without its line ending
+	Before []string `json:"before,omitempty"` // Up to GrepOptions.Before preceding lines
+	After  []string `json:"after,omitempty"`  // Up to GrepOptions.After following lines
+}
+
+// Grep searches the contents of the regular files below root for pattern, a
+// regular expression in the syntax of the regexp package unless
+// opts.Literal is set. Files are searched concurrently, and binary files,
+// those with a NUL byte near the start, are skipped. Matches are returned in
+// walk order and, within a file, in line order.
+func Grep(ctx context.Context, root, pattern string, opts GrepOptions) ([]GrepMatch, error) {
+	expr := pattern
+	if opts.Literal {
+		expr = regexp.QuoteMeta(pattern)
+	}
+	if opts.IgnoreCase {
+		expr = "(?i)" + expr
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	errs := newErrorList("grep", opts.Walk.ContinueOnError)
+
+	type grepJob struct {
+		index int
+		path  string
+	}
+	type grepResult struct {
+		index   int
+		matches []GrepMatch
+	}
+	var mu sync.Mutex
+	var results []grepResult
+	var firstErr error
+	searched := 0
+
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	jobs := make(chan grepJob)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				matches, err := grepFile(ctx, job.path, re, opts)
+				if err != nil && ctx.Err() == nil {
+					err = errs.record(job.path, err)
+				}
+				mu.Lock()
+				switch {
+				case err == nil:
+					searched++
+					if len(matches) > 0 {
+						results = append(results, grepResult{job.index, matches})
+					}
+				case err != errSkipped && !errs.skipVanished(job.path, err) && firstErr == nil:
+					firstErr = err
+					cancel()
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	index := 0
+	w := &walker{ctx: ctx, opts: opts.Walk, errs: errs, fn: func(entry WalkEntry) error {
+		if !entry.Info.Mode().IsRegular() {
+			return nil
+		}
+		select {
+		case jobs <- grepJob{index, entry.Path}:
+			index++
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}}
+	walkErr := w.run(root)
+	close(jobs)
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if walkErr != nil {
+		return nil, walkErr
+	}
+
+	sort.Slice(results, func(i, j int) bool { return results[i].index < results[j].index })
+	var matches []GrepMatch
+	for _, result := range results {
+		matches = append(matches, result.matches...)
+	}
+	return matches, errs.err(searched)
+}
+
+// grepFile returns the lines of the file at path that match re, or nothing
+// if the file is binary.
+func grepFile(ctx context.Context, path string, re *regexp.Regexp, opts GrepOptions) ([]GrepMatch, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := bufio.NewReaderSize(f, 64*1024)
+	head, err := r.Peek(binarySniffLen)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, err
+	}
+	if bytes.IndexByte(head, 0) >= 0 {
+		return nil, nil
+	}
+
+	var matches []GrepMatch
+	var before []string
+	pending := 0 // first match still collecting After lines
+	for n := 1; ; n++ {
+		if n%1024 == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+		line, err := r.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		if line == "" && err == io.EOF {
+			break
+		}
+		line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+
+		for pending < len(matches) && len(matches[pending].After) >= opts.After {
+			pending++
+		}
+		for i := pending; i < len(matches); i++ {
+			matches[i].After = append(matches[i].After, line)
+		}
+		if opts.MaxCount <= 0 || len(matches) < opts.MaxCount {
+			if loc := re.FindStringIndex(line); loc != nil {
+				matches = append(matches, GrepMatch{
+					Path:   path,
+					Line:   n,
+					Column: loc[0] + 1,
+					Text:   line,
+					Before: append([]string(nil), before...),
+				})
+			}
+		}
+		if opts.Before > 0 {
+			if before = append(before, line); len(before) > opts.Before {
+				before = before[1:]
+			}
+		}
+
+		if err == io.EOF {
+			break
+		}
+		if last := len(matches) - 1; opts.MaxCount > 0 && last+1 >= opts.MaxCount && len(matches[last].After) >= opts.After {
+			break
+		}
+	}
+	return matches, nil
+}
@@ -0,0 +1,79 @@
+package fsutils_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/utsav-56/go_fs_utils/fsutils"
+)
+
+func TestGrep(t *testing.T) {
+	// Create a temporary directory for our tests
+	tempDir, err := os.MkdirTemp("", "fsutils-grep-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	fsutils.Mkdir(filepath.Join(tempDir, "sub"))
+	os.WriteFile(filepath.Join(tempDir, "a.txt"), []byte("one\ntwo TODO\nthree\nfour\nfive todo\r\nsix"), 0644)
+	os.WriteFile(filepath.Join(tempDir, "sub", "b.go"), []byte("// TODO: x.y\n"), 0644)
+	os.WriteFile(filepath.Join(tempDir, "sub", "bin.dat"), []byte("TODO\x00\x01"), 0644)
+
+	grep := func(pattern string, opts fsutils.GrepOptions) []fsutils.GrepMatch {
+		t.Helper()
+		matches, err := fsutils.Grep(context.Background(), tempDir, pattern, opts)
+		if err != nil {
+			t.Fatalf("Grep(%q) failed: %v", pattern, err)
+		}
+		return matches
+	}
+
+	// Test regexp search in walk order, skipping the binary file
+	got := grep(`TO+DO`, fsutils.GrepOptions{Workers: 2})
+	want := []fsutils.GrepMatch{
+		{Path: filepath.Join(tempDir, "a.txt"), Line: 2, Column: 5, Text: "two TODO"},
+		{Path: filepath.Join(tempDir, "sub", "b.go"), Line: 1, Column: 4, Text: "// TODO: x.y"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Grep returned %+v, want %+v", got, want)
+	}
+
+	// Test case folding and context lines
+	got = grep("todo", fsutils.GrepOptions{IgnoreCase: true, Before: 1, After: 2, Walk: fsutils.WalkOptions{MaxDepth: 1}})
+	want = []fsutils.GrepMatch{
+		{Path: filepath.Join(tempDir, "a.txt"), Line: 2, Column: 5, Text: "two TODO", Before: []string{"one"}, After: []string{"three", "four"}},
+		{Path: filepath.Join(tempDir, "a.txt"), Line: 5, Column: 6, Text: "five todo", Before: []string{"four"}, After: []string{"six"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Grep with context returned %+v, want %+v", got, want)
+	}
+
+	// Test literal patterns, walk filters and MaxCount
+	got = grep("x.y", fsutils.GrepOptions{Literal: true, Walk: fsutils.WalkOptions{Filter: fsutils.Ext("go")}})
+	if len(got) != 1 || got[0].Column != 10 {
+		t.Errorf("Literal Grep returned %+v", got)
+	}
+	if got = grep("(?i)o", fsutils.GrepOptions{MaxCount: 1}); len(got) != 2 {
+		t.Errorf("Grep with MaxCount 1 returned %d matches, want one per file", len(got))
+	}
+
+	// Test an invalid pattern
+	if _, err := fsutils.Grep(context.Background(), tempDir, "(", fsutils.GrepOptions{}); err == nil {
+		t.Errorf("Grep with an invalid pattern succeeded")
+	}
+
+	// Test unreadable files are reported with ContinueOnError
+	if os.Geteuid() != 0 {
+		os.Chmod(filepath.Join(tempDir, "sub", "b.go"), 0)
+		matches, err := fsutils.Grep(context.Background(), tempDir, "TODO", fsutils.GrepOptions{Walk: fsutils.WalkOptions{ContinueOnError: true}})
+		var multi *fsutils.MultiError
+		if !errors.As(err, &multi) || len(multi.Errors) != 1 || len(matches) != 1 {
+			t.Errorf("Grep with an unreadable file returned %d matches and %v", len(matches), err)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+// Package fsutils provides filesystem utility functions for Go applications.
+package fsutils
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// ErrUnsafePath is wrapped by the errors of destructive operations that
+// refuse their target. Check for it with errors.Is.
+var ErrUnsafePath = errors.New("refusing to remove a protected path")
+
+// GuardOptions adjusts the checks CheckRemovable makes before a destructive
+// operation.
+type GuardOptions struct {
+	// AllowedRoots, if set, limits removals to paths strictly inside one of
+	// these directories, in place of the roots set with SetAllowedRoots.
+	AllowedRoots []string
+	// Unsafe turns every check off.
+	Unsafe bool
+}
+
+var (
+	allowedRootsMu sync.RWMutex
+	allowedRoots   []string
+)
+
+// SetAllowedRoots limits every guarded removal in the process, including
+// RmDir, to paths strictly inside one of roots, unless GuardOptions says
+// otherwise. Calling it with no roots lifts the limit.
+func SetAllowedRoots(roots ...string) {
+	allowedRootsMu.Lock()
+	defer allowedRootsMu.Unlock()
+	allowedRoots = append([]string(nil), roots...)
+}
+
+// CheckRemovable returns an error wrapping ErrUnsafePath if path is a
+// dangerous thing to delete, and nil otherwise. It refuses an empty path,
+// the root of a filesystem, a mount point, the home directory or the current
+// working directory or any directory above them, and paths outside the allowed
+// roots if there are any. Symlinks in the parent directories are resolved
+// first, but not a symlink at path itself unless path ends in a separator,
+// since removing the link leaves its target alone. RmDir, RmDirContext and
+// SyncDir call it before removing anything.
+func CheckRemovable(path string, opts GuardOptions) error {
+	if opts.Unsafe {
+		return nil
+	}
+	refuse := func(reason string) error {
+		return &os.PathError{Op: "remove", Path: path, Err: fmt.Errorf("%w: %s", ErrUnsafePath, reason)}
+	}
+	if path == "" {
+		return refuse("empty path")
+	}
+	abs, err := resolveParent(path)
+	if err != nil {
+		return err
+	}
+	if hasTrailingSeparator(path) {
+		abs = resolvePath(abs) // "link/" reaches through the link
+	}
+	if filepath.Dir(abs) == abs {
+		return refuse("filesystem root")
+	}
+	if home, err := os.UserHomeDir(); err == nil && isWithin(resolvePath(home), abs) {
+		return refuse("home directory or one of its parents")
+	}
+	if cwd, err := os.Getwd(); err == nil && isWithin(resolvePath(cwd), abs) {
+		return refuse("current directory or one of its parents")
+	}
+	if info, err := os.Lstat(abs); err == nil && info.IsDir() && isMountPoint(abs) {
+		return refuse("mount point")
+	}
+
+	roots := opts.AllowedRoots
+	if roots == nil {
+		allowedRootsMu.RLock()
+		roots = allowedRoots
+		allowedRootsMu.RUnlock()
+	}
+	if len(roots) == 0 {
+		return nil
+	}
+	for _, root := range roots {
+		if root := resolvePath(root); abs != root && isWithin(abs, root) {
+			return nil
+		}
+	}
+	return refuse("outside the allowed roots")
+}
+
+// resolveParent returns the absolute form of path with the symlinks in its
+// parent directories resolved.
+func resolveParent(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	if filepath.Dir(abs) == abs {
+		return abs, nil
+	}
+	return filepath.Join(resolvePath(filepath.Dir(abs)), filepath.Base(abs)), nil
+}
+
+// resolvePath returns the absolute form of path with all symlinks resolved,
+// or just the absolute form if it cannot be resolved.
+func resolvePath(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		return resolved
+	}
+	return abs
+}
+
+// isWithin reports whether the absolute path is dir or below it.
+func isWithin(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// isMountPoint reports whether the directory dir is where a filesystem is
+// mounted: it is listed as one, or its parent is on another device.
+func isMountPoint(dir string) bool {
+	for _, point := range mountPoints() {
+		if point == dir {
+			return true
+		}
+	}
+	return !sameDevice(dir, filepath.Dir(dir))
+}
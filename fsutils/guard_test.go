@@ -0,0 +1,108 @@
+package fsutils_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+
+	"github.com/utsav-56/go_fs_utils/fsutils"
+)
+
+func TestCheckRemovable(t *testing.T) {
+	// Create a temporary directory for our tests
+	tempDir, err := os.MkdirTemp("", "fsutils-guard-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	allowed := filepath.Join(tempDir, "allowed")
+	fsutils.Mkdir(filepath.Join(allowed, "sub"))
+	home, _ := os.UserHomeDir()
+	homeLink := filepath.Join(tempDir, "home-link")
+	os.Symlink(home, homeLink)
+
+	refused := []string{"", ".", "..", string(filepath.Separator), home, filepath.Dir(home)}
+	if runtime.GOOS == "linux" && fsutils.DirExists("/proc") {
+		refused = append(refused, "/proc")
+	}
+	for _, path := range refused {
+		if err := fsutils.CheckRemovable(path, fsutils.GuardOptions{}); !errors.Is(err, fsutils.ErrUnsafePath) {
+			t.Errorf("CheckRemovable(%q) returned %v, want ErrUnsafePath", path, err)
+		}
+		if err := fsutils.CheckRemovable(path, fsutils.GuardOptions{Unsafe: true}); err != nil {
+			t.Errorf("CheckRemovable(%q) with Unsafe returned %v", path, err)
+		}
+	}
+	if err := fsutils.CheckRemovable(homeLink+string(filepath.Separator), fsutils.GuardOptions{}); !errors.Is(err, fsutils.ErrUnsafePath) {
+		t.Errorf("CheckRemovable of a symlink to home with a trailing separator returned %v", err)
+	}
+
+	// Test ordinary paths, and a symlink to home, whose removal spares home
+	for _, path := range []string{filepath.Join(tempDir, "missing"), allowed, homeLink} {
+		if err := fsutils.CheckRemovable(path, fsutils.GuardOptions{}); err != nil {
+			t.Errorf("CheckRemovable(%q) returned %v", path, err)
+		}
+	}
+
+	// Test allowed roots, per call and for the whole process
+	opts := fsutils.GuardOptions{AllowedRoots: []string{allowed}}
+	if err := fsutils.CheckRemovable(filepath.Join(allowed, "sub"), opts); err != nil {
+		t.Errorf("CheckRemovable inside an allowed root returned %v", err)
+	}
+	for _, path := range []string{allowed, homeLink} {
+		if err := fsutils.CheckRemovable(path, opts); !errors.Is(err, fsutils.ErrUnsafePath) {
+			t.Errorf("CheckRemovable(%q) outside the allowed roots returned %v", path, err)
+		}
+	}
+	fsutils.SetAllowedRoots(allowed)
+	defer fsutils.SetAllowedRoots()
+	if err := fsutils.RmDir(homeLink); !errors.Is(err, fsutils.ErrUnsafePath) {
+		t.Errorf("RmDir outside the allowed roots returned %v", err)
+	}
+	if err := fsutils.RmDir(filepath.Join(allowed, "sub")); err != nil || fsutils.DirExists(filepath.Join(allowed, "sub")) {
+		t.Errorf("RmDir inside the allowed roots failed: %v", err)
+	}
+}
+
+func TestRemoveDryRun(t *testing.T) {
+	// Create a temporary directory for our tests
+	tempDir, err := os.MkdirTemp("", "fsutils-dryrun-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	root := filepath.Join(tempDir, "root")
+	fsutils.Mkdir(filepath.Join(root, "sub"))
+	os.WriteFile(filepath.Join(root, "sub", "file.txt"), nil, 0644)
+
+	// Test a dry run lists entries before their directories and removes nothing
+	var listed []string
+	opts := fsutils.RemoveOptions{DryRun: true, OnRemove: func(path string) { listed = append(listed, path) }}
+	if err := fsutils.RmDirContext(context.Background(), root, opts); err != nil {
+		t.Fatalf("RmDirContext dry run failed: %v", err)
+	}
+	want := []string{filepath.Join(root, "sub", "file.txt"), filepath.Join(root, "sub"), root}
+	if !reflect.DeepEqual(listed, want) || !fsutils.FileExists(want[0]) {
+		t.Errorf("RmDirContext dry run listed %v, want %v", listed, want)
+	}
+	if err := fsutils.RmDirContext(context.Background(), ".", fsutils.RemoveOptions{DryRun: true}); !errors.Is(err, fsutils.ErrUnsafePath) {
+		t.Errorf("RmDirContext dry run of the current directory returned %v", err)
+	}
+
+	// Test SyncDir only deletes what the guard allows
+	src := filepath.Join(tempDir, "src")
+	fsutils.Mkdir(src)
+	syncOpts := fsutils.SyncOptions{Delete: true, Guard: fsutils.GuardOptions{AllowedRoots: []string{src}}}
+	if _, err := fsutils.SyncDir(context.Background(), src, root, syncOpts); !errors.Is(err, fsutils.ErrUnsafePath) {
+		t.Errorf("SyncDir deleting outside the allowed roots returned %v", err)
+	}
+	if !fsutils.DirExists(filepath.Join(root, "sub")) {
+		t.Errorf("SyncDir deleted a path the guard refused")
+	}
+}
@@ -0,0 +1,142 @@
+// Package fsutils provides filesystem utility functions for Go applications.
+package fsutils
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// HashOptions controls the behaviour of HashFileContext and HashDir.
+type HashOptions struct {
+	// Progress, if set, receives progress updates while files are read.
+	Progress ProgressFunc
+	// PreScan measures a directory before hashing it so that progress
+	// updates carry totals.
+	PreScan bool
+	// RateLimit, if set, throttles the bytes and files read per second.
+	RateLimit *RateLimiter
+	// ContinueOnError makes HashDir skip files that cannot be read and carry
+	// on. The failures are returned together as a *MultiError alongside the
+	// checksums that could be computed.
+	ContinueOnError bool
+	// Ignore and IgnoreFile leave paths out of HashDir, as they do for Walk.
+	Ignore     *IgnoreMatcher
+	IgnoreFile string
+}
+
+// HashFile returns the hex-encoded SHA-256 checksum of the file at path.
+func HashFile(path string) (string, error) {
+	return HashFileContext(context.Background(), path, HashOptions{})
+}
+
+// HashFileContext is like HashFile but stops with ctx.Err() once ctx is
+// cancelled and reports progress to opts.Progress while reading.
+func HashFileContext(ctx context.Context, path string, opts HashOptions) (string, error) {
+	t := newTracker(ctx, opts.Progress)
+	t.limit = opts.RateLimit
+	if info, err := os.Stat(path); err == nil {
+		t.progress.FilesTotal, t.progress.BytesTotal = 1, info.Size()
+	}
+	return hashFile(path, t)
+}
+
+// HashDir returns the SHA-256 checksum of every regular file below root,
+// keyed by its slash-separated path relative to root.
+func HashDir(ctx context.Context, root string, opts HashOptions) (map[string]string, error) {
+	sums := make(map[string]string)
+	t := newTracker(ctx, opts.Progress)
+	t.limit = opts.RateLimit
+	if opts.PreScan {
+		entries, bytes, err := scanTree(ctx, root, opts.Ignore, opts.IgnoreFile)
+		if err != nil {
+			return nil, err
+		}
+		t.progress.FilesTotal, t.progress.BytesTotal = entries, bytes
+	}
+
+	errs := newErrorList("hash", opts.ContinueOnError)
+	ign := newIgnoreWalk(opts.Ignore, opts.IgnoreFile)
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errs.record(path, err) == errSkipped || (errs.skipVanished(path, err) && path != root) {
+				return nil
+			}
+			return err
+		}
+		ignored, err := ign.visit(root, path, d.IsDir())
+		if err != nil {
+			if errs.record(path, err) == errSkipped {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if ignored {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return t.addFile()
+		}
+		sum, err := hashFile(path, t)
+		if err != nil && ctx.Err() == nil {
+			err = errs.record(path, err)
+		}
+		if err == errSkipped || errs.skipVanished(path, err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		sums[filepath.ToSlash(rel)] = sum
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sums, errs.err(len(sums))
+}
+
+// hashFile returns the checksum of the file at path, recording progress in t.
+func hashFile(path string, t *tracker) (string, error) {
+	if err := t.start(path); err != nil {
+		return "", err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	h := sha256.New()
+	buf := make([]byte, copyBufferSize)
+	for {
+		n, err := file.Read(buf)
+		if n > 0 {
+			h.Write(buf[:n])
+			if err := t.addBytes(int64(n)); err != nil {
+				return "", err
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	if err := t.addFile(); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
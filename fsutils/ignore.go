@@ -0,0 +1,266 @@
+// Package fsutils provides filesystem utility functions for Go applications.
+package fsutils
+
+import (
+	"bufio"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DefaultIgnoreFile is the conventional name of the per-directory ignore
+// files read by the operations whose IgnoreFile option names it.
+const DefaultIgnoreFile = ".fsutilsignore"
+
+// IgnoreMatcher decides which paths of a tree are ignored, using patterns
+// with the semantics of .gitignore files:
+//
+//   - blank lines and lines starting with "#" are skipped;
+//   - a leading "!" re-includes paths ignored by earlier patterns;
+//   - a trailing "/" only matches directories;
+//   - a pattern with a "/" at the start or in the middle is anchored to the
+//     directory of its ignore file, while one without matches at any depth;
+//   - "*", "?" and "[...]" match within a path segment, and "**" matches
+//     any number of directories.
+//
+// Later patterns take precedence over earlier ones, and patterns of an
+// ignore file in a subdirectory only apply below that directory.
+type IgnoreMatcher struct {
+	rules []ignoreRule
+}
+
+// ignoreRule is one parsed pattern of an IgnoreMatcher.
+type ignoreRule struct {
+	base     string   // Directory the pattern is relative to, "" for the root
+	segments []string // Pattern split at "/", with "**" segments for unanchored patterns
+	negate   bool
+	dirOnly  bool
+}
+
+// NewIgnoreMatcher returns a matcher for patterns given relative to the root.
+func NewIgnoreMatcher(patterns ...string) *IgnoreMatcher {
+	m := &IgnoreMatcher{}
+	m.Add(".", patterns...)
+	return m
+}
+
+// Add appends patterns as if they were read from an ignore file in base, a
+// slash-separated directory relative to the root ("." for the root).
+// Invalid patterns are skipped, as git does.
+func (m *IgnoreMatcher) Add(base string, patterns ...string) {
+	base = path.Clean(base)
+	if base == "." {
+		base = ""
+	}
+	for _, pattern := range patterns {
+		if rule, ok := parseIgnoreRule(pattern); ok {
+			rule.base = base
+			m.rules = append(m.rules, rule)
+		}
+	}
+}
+
+// AddFile reads the ignore file at file and appends its patterns relative
+// to base, as Add does.
+func (m *IgnoreMatcher) AddFile(base, file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	var patterns []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		patterns = append(patterns, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	m.Add(base, patterns...)
+	return nil
+}
+
+// Match reports whether rel, a slash-separated path relative to the root,
+// is ignored; isDir tells whether it is a directory. As in git, a path
+// inside an ignored directory is ignored whatever the patterns say about it.
+func (m *IgnoreMatcher) Match(rel string, isDir bool) bool {
+	if m == nil {
+		return false
+	}
+	rel = path.Clean(rel)
+	for i := 0; i < len(rel); i++ {
+		if rel[i] == '/' && m.match(rel[:i], true) {
+			return true
+		}
+	}
+	return m.match(rel, isDir)
+}
+
+// match is Match without the check of the parent directories.
+func (m *IgnoreMatcher) match(rel string, isDir bool) bool {
+	var rootParts []string
+	for i := len(m.rules) - 1; i >= 0; i-- {
+		r := &m.rules[i]
+		if r.dirOnly && !isDir {
+			continue
+		}
+		var parts []string
+		switch {
+		case r.base == "":
+			if rootParts == nil {
+				rootParts = strings.Split(rel, "/")
+			}
+			parts = rootParts
+		case strings.HasPrefix(rel, r.base+"/"):
+			parts = strings.Split(rel[len(r.base)+1:], "/")
+		default:
+			continue
+		}
+		if matchSegments(r.segments, parts) {
+			return !r.negate
+		}
+	}
+	return false
+}
+
+// parseIgnoreRule parses one line of an ignore file. It reports false for
+// blank lines, comments and invalid patterns.
+func parseIgnoreRule(line string) (ignoreRule, bool) {
+	var rule ignoreRule
+	line = strings.TrimSuffix(line, "\r")
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, `\ `) {
+		line = line[:len(line)-1]
+	}
+	if line == "" || line[0] == '#' {
+		return rule, false
+	}
+	if line[0] == '!' {
+		rule.negate = true
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+	if line == "" {
+		return rule, false
+	}
+
+	if !anchored {
+		rule.segments = append(rule.segments, "**")
+	}
+	for _, segment := range strings.Split(line, "/") {
+		segment = convertClasses(segment)
+		if _, err := path.Match(segment, ""); err != nil {
+			return rule, false
+		}
+		rule.segments = append(rule.segments, segment)
+	}
+	if rule.segments[len(rule.segments)-1] == "**" {
+		// A trailing "/**" matches what is inside a directory, not the directory.
+		rule.segments = append(rule.segments, "*")
+	}
+	return rule, true
+}
+
+// treeIgnores returns a matcher holding the patterns of ignore followed by
+// those of the ignore files named file in root and the directories of snap,
+// a snapshot of root, so that another tree can be judged by the same rules.
+func treeIgnores(root string, snap map[string]fs.FileInfo, ignore *IgnoreMatcher, file string) (*IgnoreMatcher, error) {
+	m := &IgnoreMatcher{}
+	if ignore != nil {
+		m.rules = append(m.rules, ignore.rules...)
+	}
+	var dirs []string
+	for rel, info := range snap {
+		if info.IsDir() {
+			dirs = append(dirs, rel)
+		}
+	}
+	// Parents come first, so patterns deeper down take precedence.
+	sort.Slice(dirs, func(i, j int) bool { return pathLess(dirs[i], dirs[j]) })
+	for _, rel := range append([]string{"."}, dirs...) {
+		err := m.AddFile(rel, filepath.Join(root, filepath.FromSlash(rel), file))
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// ignoreWalk applies the ignore options of a tree walk, reading the ignore
+// file of every directory it enters. A nil *ignoreWalk ignores nothing.
+type ignoreWalk struct {
+	m    *IgnoreMatcher // Copy of the caller's matcher, extended with ignore files
+	file string
+}
+
+// newIgnoreWalk returns the state for a walk ignoring the paths matched by m
+// and by the ignore files named file, or nil if both are unset.
+func newIgnoreWalk(m *IgnoreMatcher, file string) *ignoreWalk {
+	if m == nil && file == "" {
+		return nil
+	}
+	w := &ignoreWalk{m: &IgnoreMatcher{}, file: file}
+	if m != nil {
+		w.m.rules = append(w.m.rules, m.rules...)
+	}
+	return w
+}
+
+// ignored reports whether the entry at rel is left out of the walk. The
+// root itself never is.
+func (w *ignoreWalk) ignored(rel string, isDir bool) bool {
+	return w != nil && rel != "." && w.m.match(rel, isDir)
+}
+
+// enter reads the ignore file of the directory at dir, found at rel in the
+// walk. It returns a mark for leave, which drops the directory's patterns
+// again once its entries have been walked.
+func (w *ignoreWalk) enter(dir, rel string) (int, error) {
+	if w == nil {
+		return 0, nil
+	}
+	mark := len(w.m.rules)
+	if w.file != "" {
+		err := w.m.AddFile(rel, filepath.Join(dir, w.file))
+		if err != nil && !os.IsNotExist(err) {
+			return mark, err
+		}
+	}
+	return mark, nil
+}
+
+// visit applies the walk's rules to path, an entry of a filepath.Walk or
+// filepath.WalkDir of root, and reads the ignore file of a directory that
+// is not ignored. Patterns of sibling directories stay in place, which is
+// harmless since they only apply below their own directory.
+func (w *ignoreWalk) visit(root, path string, isDir bool) (bool, error) {
+	if w == nil {
+		return false, nil
+	}
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false, err
+	}
+	rel = filepath.ToSlash(rel)
+	if w.ignored(rel, isDir) {
+		return true, nil
+	}
+	if isDir {
+		_, err = w.enter(path, rel)
+	}
+	return false, err
+}
+
+// leave drops the patterns added since enter returned mark.
+func (w *ignoreWalk) leave(mark int) {
+	if w != nil {
+		w.m.rules = w.m.rules[:mark]
+	}
+}
@@ -0,0 +1,149 @@
+package fsutils_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/utsav-56/go_fs_utils/fsutils"
+)
+
+func TestIgnoreMatcher(t *testing.T) {
+	m := fsutils.NewIgnoreMatcher(
+		"# build outputs",
+		"*.log",
+		"!keep.log",
+		"/build",
+		"node_modules/",
+		"docs/**/*.tmp",
+		"cache/**",
+		"!cache/README",
+		`\#literal`,
+		"[!a]x",
+	)
+	m.Add("sub", "local.txt", "/anchored")
+
+	tests := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"app.log", false, true},
+		{"deep/dir/app.log", false, true},
+		{"keep.log", false, false},
+		{"build", true, true},
+		{"build/out.o", false, true},
+		{"src/build", true, false},
+		{"node_modules", true, true},
+		{"a/node_modules/pkg/index.js", false, true},
+		{"node_modules", false, false},
+		{"docs/a.tmp", false, true},
+		{"docs/x/y/a.tmp", false, true},
+		{"cache", true, false},
+		{"cache/data", false, true},
+		{"cache/README", false, false},
+		{"#literal", false, true},
+		{"bx", false, true},
+		{"ax", false, false},
+		{"sub/local.txt", false, true},
+		{"sub/deeper/local.txt", false, true},
+		{"local.txt", false, false},
+		{"sub/anchored", false, true},
+		{"sub/deeper/anchored", false, false},
+		{"main.go", false, false},
+	}
+	for _, tt := range tests {
+		if got := m.Match(tt.path, tt.isDir); got != tt.want {
+			t.Errorf("Match(%q, %v) = %v, want %v", tt.path, tt.isDir, got, tt.want)
+		}
+	}
+}
+
+func TestIgnoreFiles(t *testing.T) {
+	// Create a temporary directory for our tests
+	tempDir, err := os.MkdirTemp("", "fsutils-ignore-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	src := filepath.Join(tempDir, "src")
+	fsutils.Mkdir(filepath.Join(src, "node_modules", "pkg"))
+	fsutils.Mkdir(filepath.Join(src, "lib"))
+	os.WriteFile(filepath.Join(src, fsutils.DefaultIgnoreFile), []byte("node_modules/\n*.log\n"), 0644)
+	os.WriteFile(filepath.Join(src, "node_modules", "pkg", "index.js"), []byte("js"), 0644)
+	os.WriteFile(filepath.Join(src, "main.go"), []byte("go"), 0644)
+	os.WriteFile(filepath.Join(src, "debug.log"), []byte("log"), 0644)
+	os.WriteFile(filepath.Join(src, "lib", fsutils.DefaultIgnoreFile), []byte("!important.log\n/gen.go\n"), 0644)
+	os.WriteFile(filepath.Join(src, "lib", "important.log"), []byte("log"), 0644)
+	os.WriteFile(filepath.Join(src, "lib", "other.log"), []byte("log"), 0644)
+	os.WriteFile(filepath.Join(src, "lib", "gen.go"), []byte("go"), 0644)
+	os.WriteFile(filepath.Join(src, "lib", "lib.go"), []byte("go"), 0644)
+
+	want := []string{
+		fsutils.DefaultIgnoreFile,
+		"lib/" + fsutils.DefaultIgnoreFile,
+		"lib/important.log",
+		"lib/lib.go",
+		"main.go",
+	}
+
+	// Test the walker reads nested ignore files
+	var walked []string
+	err = fsutils.Walk(context.Background(), src, fsutils.WalkOptions{IgnoreFile: fsutils.DefaultIgnoreFile}, func(entry fsutils.WalkEntry) error {
+		if entry.Info.Mode().IsRegular() {
+			walked = append(walked, entry.Rel)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Walk failed: %v", err)
+	}
+	sort.Strings(walked)
+	if !reflect.DeepEqual(walked, want) {
+		t.Errorf("Walk visited %v, want %v", walked, want)
+	}
+
+	// Test hashing
+	sums, err := fsutils.HashDir(context.Background(), src, fsutils.HashOptions{IgnoreFile: fsutils.DefaultIgnoreFile})
+	if err != nil {
+		t.Fatalf("HashDir failed: %v", err)
+	}
+	var hashed []string
+	for rel := range sums {
+		hashed = append(hashed, rel)
+	}
+	sort.Strings(hashed)
+	if !reflect.DeepEqual(hashed, want) {
+		t.Errorf("HashDir hashed %v, want %v", hashed, want)
+	}
+
+	// Test copying with ignore files and extra patterns
+	dst := filepath.Join(tempDir, "copy")
+	opts := fsutils.CopyOptions{IgnoreFile: fsutils.DefaultIgnoreFile, Ignore: fsutils.NewIgnoreMatcher("main.go"), PreScan: true}
+	result, err := fsutils.CopyDirWithOptions(src, dst, opts)
+	if err != nil {
+		t.Fatalf("CopyDirWithOptions failed: %v", err)
+	}
+	if result.Files != 4 || fsutils.FileExists(filepath.Join(dst, "main.go")) || fsutils.DirExists(filepath.Join(dst, "node_modules")) {
+		t.Errorf("CopyDirWithOptions copied %d files, including ignored ones", result.Files)
+	}
+
+	// Test syncing leaves ignored destination paths alone
+	mirror := filepath.Join(tempDir, "mirror")
+	fsutils.Mkdir(filepath.Join(mirror, "node_modules"))
+	os.WriteFile(filepath.Join(mirror, "node_modules", "cached.js"), []byte("js"), 0644)
+	syncOpts := fsutils.SyncOptions{Delete: true, IgnoreFile: fsutils.DefaultIgnoreFile}
+	if _, err := fsutils.SyncDir(context.Background(), src, mirror, syncOpts); err != nil {
+		t.Fatalf("SyncDir failed: %v", err)
+	}
+	if !fsutils.FileExists(filepath.Join(mirror, "node_modules", "cached.js")) {
+		t.Errorf("SyncDir deleted an ignored path")
+	}
+	if fsutils.FileExists(filepath.Join(mirror, "debug.log")) || !fsutils.FileExists(filepath.Join(mirror, "lib", "important.log")) {
+		t.Errorf("SyncDir did not apply the ignore files")
+	}
+}
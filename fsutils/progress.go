@@ -0,0 +1,161 @@
+// Package fsutils provides filesystem utility functions for Go applications.
+package fsutils
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Progress reports how far a long-running operation has got.
+// The totals are zero unless they are known up front, either because a single
+// file is involved or because a pre-scan was requested.
+type Progress struct {
+	Path       string // Path currently being processed
+	BytesDone  int64  // Bytes processed so far
+	BytesTotal int64  // Bytes to process in total, if known
+	FilesDone  int    // Entries completed so far
+	FilesTotal int    // Entries to process in total, if known
+}
+
+// ProgressFunc receives progress updates. It is called synchronously from the
+// goroutines doing the work, one call at a time, so it should return quickly.
+type ProgressFunc func(Progress)
+
+// tracker accumulates progress for one operation, checks for cancellation
+// every time work is recorded and applies the operation's rate limit, if any.
+// It is safe for concurrent use once the operation has started.
+type tracker struct {
+	ctx      context.Context
+	fn       ProgressFunc
+	limit    *RateLimiter
+	quota    Size // Bytes that may be reserved in total, or zero for no limit
+	mu       sync.Mutex
+	progress Progress
+	reserved Size
+}
+
+// newTracker returns a tracker reporting to fn, which may be nil.
+func newTracker(ctx context.Context, fn ProgressFunc) *tracker {
+	return &tracker{ctx: ctx, fn: fn}
+}
+
+// start records that work on path has begun and reports whether the
+// operation has been cancelled. It waits for the rate limiter's file budget.
+func (t *tracker) start(path string) error {
+	if err := t.ctx.Err(); err != nil {
+		return err
+	}
+	if t.limit != nil {
+		if err := t.limit.WaitFile(t.ctx); err != nil {
+			return err
+		}
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.progress.Path = path
+	t.report()
+	return nil
+}
+
+// reserve claims n bytes of the quota for copying path, failing with
+// ErrQuotaExceeded if they do not fit.
+func (t *tracker) reserve(path string, n int64) error {
+	if t.quota <= 0 {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.reserved+Size(n) > t.quota {
+		return &os.PathError{Op: "copy", Path: path, Err: ErrQuotaExceeded}
+	}
+	t.reserved += Size(n)
+	return nil
+}
+
+// addBytes records n bytes of I/O, waiting for the rate limiter's byte budget.
+func (t *tracker) addBytes(n int64) error {
+	if err := t.skipBytes(n); err != nil {
+		return err
+	}
+	if t.limit != nil {
+		return t.limit.WaitBytes(t.ctx, n)
+	}
+	return nil
+}
+
+// skipBytes records n bytes that were handled without moving data, such as
+// a reflink or data verified by an earlier attempt, so they are not throttled.
+func (t *tracker) skipBytes(n int64) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.progress.BytesDone += n
+	t.report()
+	return t.ctx.Err()
+}
+
+// addFile records a completed entry.
+func (t *tracker) addFile() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.progress.FilesDone++
+	t.report()
+	return t.ctx.Err()
+}
+
+// report forwards the current progress to the callback, if any.
+// The caller must hold t.mu.
+func (t *tracker) report() {
+	if t.fn != nil {
+		t.fn(t.progress)
+	}
+}
+
+// progressWriter counts the bytes written through it into a tracker.
+// It deliberately has no ReadFrom method, so io.Copy goes through its buffer.
+type progressWriter struct {
+	w *os.File
+	t *tracker
+}
+
+// Write writes p and records its length, failing once the operation is cancelled.
+func (pw progressWriter) Write(p []byte) (int, error) {
+	n, err := pw.w.Write(p)
+	if err == nil {
+		err = pw.t.addBytes(int64(n))
+	}
+	return n, err
+}
+
+// scanTree counts the entries under root and the bytes held in its regular files,
+// leaving out the paths ignored by ignore and the ignore files named ignoreFile.
+// It is used to fill in the totals before a recursive operation starts.
+func scanTree(ctx context.Context, root string, ignore *IgnoreMatcher, ignoreFile string) (entries int, bytes int64, err error) {
+	ign := newIgnoreWalk(ignore, ignoreFile)
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if ignored, err := ign.visit(root, path, d.IsDir()); err != nil || ignored {
+			if err == nil && d.IsDir() {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		entries++
+		if d.Type().IsRegular() {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			bytes += info.Size()
+		}
+		return nil
+	})
+	return entries, bytes, err
+}
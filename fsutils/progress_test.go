@@ -0,0 +1,73 @@
+package fsutils_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/utsav-56/go_fs_utils/fsutils"
+)
+
+func TestContextAndProgress(t *testing.T) {
+	// Create a temporary directory for our tests
+	tempDir, err := os.MkdirTemp("", "fsutils-progress-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	src := filepath.Join(tempDir, "src")
+	if err := fsutils.Mkdir(filepath.Join(src, "nested")); err != nil {
+		t.Fatalf("Failed to create test directory: %v", err)
+	}
+	for _, name := range []string{"a.txt", "b.txt", filepath.Join("nested", "c.txt")} {
+		if err := os.WriteFile(filepath.Join(src, name), make([]byte, 1000), 0644); err != nil {
+			t.Fatalf("Failed to write test file: %v", err)
+		}
+	}
+
+	// Test CopyDirContext reports totals and finishes at them
+	var last fsutils.Progress
+	opts := fsutils.CopyOptions{PreScan: true, Progress: func(p fsutils.Progress) { last = p }}
+	if _, err := fsutils.CopyDirContext(context.Background(), src, filepath.Join(tempDir, "copy"), opts); err != nil {
+		t.Fatalf("CopyDirContext failed: %v", err)
+	}
+	if last.FilesTotal != 5 || last.BytesTotal != 3000 {
+		t.Errorf("CopyDirContext pre-scan returned wrong totals: %+v", last)
+	}
+	if last.FilesDone != last.FilesTotal || last.BytesDone != last.BytesTotal {
+		t.Errorf("CopyDirContext finished short of its totals: %+v", last)
+	}
+
+	// Test a cancelled context stops the copy
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = fsutils.CopyDirContext(ctx, src, filepath.Join(tempDir, "cancelled"), fsutils.CopyOptions{})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("CopyDirContext ignored cancellation: %v", err)
+	}
+	if _, err := fsutils.HashFileContext(ctx, filepath.Join(src, "a.txt"), fsutils.HashOptions{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("HashFileContext ignored cancellation: %v", err)
+	}
+
+	// Test MoveContext
+	moved := filepath.Join(tempDir, "moved")
+	if err := fsutils.MoveContext(context.Background(), filepath.Join(tempDir, "copy"), moved, fsutils.CopyOptions{}); err != nil {
+		t.Fatalf("MoveContext failed: %v", err)
+	}
+	if !fsutils.FileExists(filepath.Join(moved, "nested", "c.txt")) {
+		t.Errorf("MoveContext didn't move the tree to %s", moved)
+	}
+
+	// Test RmDirContext counts every removed entry
+	removed := 0
+	err = fsutils.RmDirContext(context.Background(), moved, fsutils.RemoveOptions{Progress: func(p fsutils.Progress) { removed = p.FilesDone }})
+	if err != nil {
+		t.Fatalf("RmDirContext failed: %v", err)
+	}
+	if fsutils.DirExists(moved) || removed != 5 {
+		t.Errorf("RmDirContext left the tree behind or miscounted: removed %d", removed)
+	}
+}
@@ -0,0 +1,438 @@
+// Package fsutils provides filesystem utility functions for Go applications.
+package fsutils
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Predicate tests an entry found by Walk. It can be used directly as
+// WalkOptions.Filter.
+type Predicate func(entry WalkEntry) bool
+
+// Comparison is the operator of a numeric predicate such as SizeIs.
+type Comparison int
+
+const (
+	CmpLess         Comparison = iota // <
+	CmpLessEqual                      // <=
+	CmpEqual                          // =
+	CmpGreaterEqual                   // >=
+	CmpGreater                        // >
+)
+
+// compare applies c to a and b.
+func (c Comparison) compare(a, b int64) bool {
+	switch c {
+	case CmpLess:
+		return a < b
+	case CmpLessEqual:
+		return a <= b
+	case CmpGreaterEqual:
+		return a >= b
+	case CmpGreater:
+		return a > b
+	default:
+		return a == b
+	}
+}
+
+// And matches entries that match every predicate.
+func And(preds ...Predicate) Predicate {
+	return func(entry WalkEntry) bool {
+		for _, p := range preds {
+			if !p(entry) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Or matches entries that match at least one predicate.
+func Or(preds ...Predicate) Predicate {
+	return func(entry WalkEntry) bool {
+		for _, p := range preds {
+			if p(entry) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Not matches entries that p does not match.
+func Not(p Predicate) Predicate {
+	return func(entry WalkEntry) bool { return !p(entry) }
+}
+
+// Name matches entries whose base name matches the pattern, in the syntax
+// of Match.
+func Name(pattern string) Predicate {
+	return func(entry WalkEntry) bool {
+		ok, _ := Match(pattern, entry.Info.Name())
+		return ok
+	}
+}
+
+// PathGlob matches entries whose path relative to the walk root matches
+// the slash-separated pattern, in the syntax of Match.
+func PathGlob(pattern string) Predicate {
+	return func(entry WalkEntry) bool {
+		ok, _ := Match(pattern, entry.Rel)
+		return ok
+	}
+}
+
+// Ext matches entries with one of the given extensions, compared without
+// regard to case and with or without the leading dot.
+func Ext(exts ...string) Predicate {
+	want := make(map[string]bool, len(exts))
+	for _, ext := range exts {
+		want["."+strings.ToLower(strings.TrimPrefix(ext, "."))] = true
+	}
+	return func(entry WalkEntry) bool {
+		return want[strings.ToLower(path.Ext(entry.Info.Name()))]
+	}
+}
+
+// IsFile matches regular files.
+func IsFile() Predicate {
+	return func(entry WalkEntry) bool { return entry.Info.Mode().IsRegular() }
+}
+
+// IsDir matches directories.
+func IsDir() Predicate {
+	return func(entry WalkEntry) bool { return entry.Info.IsDir() }
+}
+
+// IsSymlink matches symbolic links. Walks that follow symlinks report
+// their targets instead, so this only matches dangling links there.
+func IsSymlink() Predicate {
+	return func(entry WalkEntry) bool { return entry.Info.Mode()&fs.ModeSymlink != 0 }
+}
+
+// SizeIs matches entries whose size compares to size as cmp says.
+func SizeIs(cmp Comparison, size Size) Predicate {
+	return func(entry WalkEntry) bool { return cmp.compare(entry.Info.Size(), int64(size)) }
+}
+
+// AgeIs matches entries whose time since their last modification compares
+// to age as cmp says; AgeIs(CmpLess, 24*time.Hour) matches files changed in
+// the last day. Ages are measured from when the predicate is created.
+func AgeIs(cmp Comparison, age time.Duration) Predicate {
+	now := time.Now()
+	return func(entry WalkEntry) bool {
+		return cmp.compare(int64(now.Sub(entry.Info.ModTime())), int64(age))
+	}
+}
+
+// DepthIs matches entries whose depth below the walk root compares to depth
+// as cmp says.
+func DepthIs(cmp Comparison, depth int) Predicate {
+	return func(entry WalkEntry) bool { return cmp.compare(int64(entry.Depth), int64(depth)) }
+}
+
+// Find walks root and returns the paths of the entries matching query, in
+// walk order. See ParseQuery for the query syntax; opts.Filter, if set,
+// must match as well.
+func Find(ctx context.Context, root, query string, opts WalkOptions) ([]string, error) {
+	pred, err := ParseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	return FindWhere(ctx, root, pred, opts)
+}
+
+// FindWhere walks root and returns the paths of the entries matching pred,
+// in walk order. opts.Filter, if set, must match as well.
+func FindWhere(ctx context.Context, root string, pred Predicate, opts WalkOptions) ([]string, error) {
+	if opts.Filter != nil {
+		pred = And(Predicate(opts.Filter), pred)
+	}
+	opts.Filter = pred
+	var paths []string
+	err := Walk(ctx, root, opts, func(entry WalkEntry) error {
+		paths = append(paths, entry.Path)
+		return nil
+	})
+	return paths, err
+}
+
+// ParseQuery parses a find(1)-style query into a Predicate, for example
+//
+//	ext:go and size>1M and mtime<7d and not path:vendor/**
+//
+// A query combines terms with "and", "or", "not" and parentheses; terms
+// next to each other are joined with "and", which binds tighter than "or".
+// The terms are:
+//
+//	name:GLOB       base name matches GLOB                      (Name)
+//	path:GLOB       path relative to the root matches GLOB      (PathGlob)
+//	ext:go,ts       extension is one of the list                (Ext)
+//	type:f|d|l      regular file, directory or symlink          (IsFile, IsDir, IsSymlink)
+//	size OP SIZE    size compared with a Size such as 1M        (SizeIs)
+//	mtime OP AGE    time since modification, such as 7d or 12h (AgeIs)
+//	depth OP N      depth below the root                        (DepthIs)
+//
+// OP is one of <, <=, =, >=, > or ":", which means "=". Ages are Go
+// durations, also accepting d for days and w for weeks. Values containing
+// spaces, parentheses or operators can be double-quoted.
+func ParseQuery(query string) (Predicate, error) {
+	tokens, err := lexQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	p := &queryParser{tokens: tokens}
+	pred, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok != nil {
+		return nil, fmt.Errorf("query: unexpected %q", tok.text)
+	}
+	return pred, nil
+}
+
+// queryToken is one lexical element of a query.
+type queryToken struct {
+	text   string
+	op     bool // an operator or parenthesis rather than a word
+	quoted bool
+}
+
+// queryOperators are the operator tokens, longest first.
+var queryOperators = []string{"<=", ">=", "<", ">", "=", ":", "(", ")"}
+
+// lexQuery splits a query into words, quoted strings and operators.
+func lexQuery(query string) ([]queryToken, error) {
+	var tokens []queryToken
+	for i := 0; i < len(query); {
+		c := query[i]
+		if unicode.IsSpace(rune(c)) {
+			i++
+			continue
+		}
+		if c == '"' {
+			end := strings.IndexByte(query[i+1:], '"')
+			if end < 0 {
+				return nil, fmt.Errorf("query: unterminated quote")
+			}
+			tokens = append(tokens, queryToken{text: query[i+1 : i+1+end], quoted: true})
+			i += end + 2
+			continue
+		}
+		matched := false
+		for _, op := range queryOperators {
+			if strings.HasPrefix(query[i:], op) {
+				tokens = append(tokens, queryToken{text: op, op: true})
+				i += len(op)
+				matched = true
+				break
+			}
+		}
+		if matched {
+			continue
+		}
+		start := i
+		for i < len(query) && !unicode.IsSpace(rune(query[i])) && !strings.ContainsRune("\"<>=:()", rune(query[i])) {
+			i++
+		}
+		tokens = append(tokens, queryToken{text: query[start:i]})
+	}
+	return tokens, nil
+}
+
+// queryParser is a recursive-descent parser over the tokens of a query.
+type queryParser struct {
+	tokens []queryToken
+	pos    int
+}
+
+// peek returns the next token without consuming it, or nil at the end.
+func (p *queryParser) peek() *queryToken {
+	if p.pos >= len(p.tokens) {
+		return nil
+	}
+	return &p.tokens[p.pos]
+}
+
+// next consumes and returns the next token, or nil at the end.
+func (p *queryParser) next() *queryToken {
+	tok := p.peek()
+	if tok != nil {
+		p.pos++
+	}
+	return tok
+}
+
+// keyword reports whether tok is the unquoted word w.
+func (tok *queryToken) keyword(w string) bool {
+	return tok != nil && !tok.op && !tok.quoted && strings.EqualFold(tok.text, w)
+}
+
+// parseOr parses terms joined with "or".
+func (p *queryParser) parseOr() (Predicate, error) {
+	preds := []Predicate{}
+	for {
+		pred, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		preds = append(preds, pred)
+		if !p.peek().keyword("or") {
+			break
+		}
+		p.next()
+	}
+	if len(preds) == 1 {
+		return preds[0], nil
+	}
+	return Or(preds...), nil
+}
+
+// parseAnd parses terms joined with "and" or written next to each other.
+func (p *queryParser) parseAnd() (Predicate, error) {
+	preds := []Predicate{}
+	for {
+		pred, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		preds = append(preds, pred)
+		tok := p.peek()
+		if tok.keyword("and") {
+			p.next()
+			continue
+		}
+		if tok == nil || tok.keyword("or") || (tok.op && tok.text == ")") {
+			break
+		}
+	}
+	if len(preds) == 1 {
+		return preds[0], nil
+	}
+	return And(preds...), nil
+}
+
+// parseUnary parses "not", a parenthesised query or a term.
+func (p *queryParser) parseUnary() (Predicate, error) {
+	tok := p.next()
+	switch {
+	case tok == nil:
+		return nil, fmt.Errorf("query: unexpected end")
+	case tok.keyword("not"):
+		pred, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return Not(pred), nil
+	case tok.op && tok.text == "(":
+		pred, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing == nil || !closing.op || closing.text != ")" {
+			return nil, fmt.Errorf("query: missing )")
+		}
+		return pred, nil
+	case tok.op:
+		return nil, fmt.Errorf("query: unexpected %q", tok.text)
+	}
+
+	key := strings.ToLower(tok.text)
+	op := p.next()
+	if op == nil || !op.op || op.text == "(" || op.text == ")" {
+		return nil, fmt.Errorf("query: expected an operator after %q", tok.text)
+	}
+	value := p.next()
+	if value == nil || value.op {
+		return nil, fmt.Errorf("query: expected a value after %s%s", tok.text, op.text)
+	}
+	return queryTerm(key, op.text, value.text)
+}
+
+// queryComparisons maps operator tokens to comparisons.
+var queryComparisons = map[string]Comparison{
+	"<": CmpLess, "<=": CmpLessEqual, "=": CmpEqual, ":": CmpEqual, ">=": CmpGreaterEqual, ">": CmpGreater,
+}
+
+// queryTerm builds the predicate for the term key op value.
+func queryTerm(key, op, value string) (Predicate, error) {
+	cmp := queryComparisons[op]
+	switch key {
+	case "size", "mtime", "depth":
+	default:
+		if cmp != CmpEqual {
+			return nil, fmt.Errorf("query: %s only supports \":\"", key)
+		}
+	}
+	switch key {
+	case "name", "path":
+		if _, err := Match(value, ""); err != nil {
+			return nil, fmt.Errorf("query: invalid pattern %q: %v", value, err)
+		}
+		if key == "name" {
+			return Name(value), nil
+		}
+		return PathGlob(value), nil
+	case "ext":
+		return Ext(strings.Split(value, ",")...), nil
+	case "type":
+		switch value {
+		case "f", "file":
+			return IsFile(), nil
+		case "d", "dir":
+			return IsDir(), nil
+		case "l", "symlink":
+			return IsSymlink(), nil
+		}
+		return nil, fmt.Errorf("query: unknown type %q", value)
+	case "size":
+		size, err := ParseSize(value)
+		if err != nil {
+			return nil, fmt.Errorf("query: %v", err)
+		}
+		return SizeIs(cmp, size), nil
+	case "mtime":
+		age, err := ParseAge(value)
+		if err != nil {
+			return nil, fmt.Errorf("query: %v", err)
+		}
+		return AgeIs(cmp, age), nil
+	case "depth":
+		depth, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("query: invalid depth %q", value)
+		}
+		return DepthIs(cmp, depth), nil
+	}
+	return nil, fmt.Errorf("query: unknown term %q", key)
+}
+
+// ParseAge parses a Go duration, also accepting whole or fractional days
+// ("7d") and weeks ("2w"), as the mtime terms of a query do.
+func ParseAge(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, fmt.Errorf("invalid age %q", value)
+	}
+	units := map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour}
+	if unit, ok := units[value[len(value)-1:]]; ok {
+		n, err := strconv.ParseFloat(value[:len(value)-1], 64)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid age %q", value)
+		}
+		return time.Duration(n * float64(unit)), nil
+	}
+	age, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid age %q", value)
+	}
+	return age, nil
+}
@@ -0,0 +1,115 @@
+package fsutils_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/utsav-56/go_fs_utils/fsutils"
+)
+
+func TestFind(t *testing.T) {
+	// Create a temporary directory for our tests
+	tempDir, err := os.MkdirTemp("", "fsutils-find-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	fsutils.Mkdir(filepath.Join(tempDir, "vendor", "lib"))
+	fsutils.Mkdir(filepath.Join(tempDir, "cmd"))
+	os.WriteFile(filepath.Join(tempDir, "main.go"), make([]byte, 2048), 0644)
+	os.WriteFile(filepath.Join(tempDir, "README.md"), []byte("readme"), 0644)
+	os.WriteFile(filepath.Join(tempDir, "cmd", "tool.go"), []byte("package main"), 0644)
+	os.WriteFile(filepath.Join(tempDir, "vendor", "lib", "lib.go"), make([]byte, 4096), 0644)
+	old := time.Now().Add(-30 * 24 * time.Hour)
+	os.Chtimes(filepath.Join(tempDir, "cmd", "tool.go"), old, old)
+
+	find := func(query string) []string {
+		t.Helper()
+		paths, err := fsutils.Find(context.Background(), tempDir, query, fsutils.WalkOptions{})
+		if err != nil {
+			t.Fatalf("Find(%q) failed: %v", query, err)
+		}
+		var rels []string
+		for _, p := range paths {
+			rel, _ := filepath.Rel(tempDir, p)
+			rels = append(rels, filepath.ToSlash(rel))
+		}
+		return rels
+	}
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"ext:go", []string{"cmd/tool.go", "main.go", "vendor/lib/lib.go"}},
+		{"ext:go and not path:vendor/**", []string{"cmd/tool.go", "main.go"}},
+		{"ext:go size>1K", []string{"main.go", "vendor/lib/lib.go"}},
+		{"ext:go and mtime<7d", []string{"main.go", "vendor/lib/lib.go"}},
+		{"type:d and depth>=1", []string{"cmd", "vendor", "vendor/lib"}},
+		{"name:README.* or (ext:go and depth=2)", []string{"README.md", "cmd/tool.go"}},
+		{`path:"**/lib.go"`, []string{"vendor/lib/lib.go"}},
+		{"ext:md,GO size <= 100", []string{"README.md", "cmd/tool.go"}},
+	}
+	for _, tt := range tests {
+		if got := find(tt.query); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Find(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+
+	// Test the builder API matches the parsed query
+	pred := fsutils.And(
+		fsutils.Ext("go"),
+		fsutils.SizeIs(fsutils.CmpGreater, fsutils.KiB),
+		fsutils.Not(fsutils.PathGlob("vendor/**")),
+	)
+	paths, err := fsutils.FindWhere(context.Background(), tempDir, pred, fsutils.WalkOptions{})
+	if err != nil {
+		t.Fatalf("FindWhere failed: %v", err)
+	}
+	if want := []string{filepath.Join(tempDir, "main.go")}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("FindWhere returned %v, want %v", paths, want)
+	}
+}
+
+func TestParseQueryErrors(t *testing.T) {
+	for _, query := range []string{
+		"",
+		"ext:go and",
+		"(ext:go",
+		"ext:go)",
+		"color:red",
+		"size>lots",
+		"mtime<soon",
+		"type:x",
+		"name<foo",
+		`name:"[`,
+		`name:"unterminated`,
+	} {
+		if _, err := fsutils.ParseQuery(query); err == nil {
+			t.Errorf("ParseQuery(%q) succeeded, want an error", query)
+		}
+	}
+}
+
+func TestParseAge(t *testing.T) {
+	for value, want := range map[string]time.Duration{
+		"90m":  90 * time.Minute,
+		"7d":   7 * 24 * time.Hour,
+		"1.5d": 36 * time.Hour,
+		"2w":   14 * 24 * time.Hour,
+	} {
+		if got, err := fsutils.ParseAge(value); err != nil || got != want {
+			t.Errorf("ParseAge(%q) = %v, %v, want %v", value, got, err, want)
+		}
+	}
+	for _, value := range []string{"", "d", "-1d", "soon"} {
+		if _, err := fsutils.ParseAge(value); err == nil {
+			t.Errorf("ParseAge(%q) succeeded, want an error", value)
+		}
+	}
+}
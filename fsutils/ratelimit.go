@@ -0,0 +1,135 @@
+// Package fsutils provides filesystem utility functions for Go applications.
+package fsutils
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RateLimiter throttles copy and hash operations to a number of bytes and
+// files per second. It is a pair of token buckets that may be shared by any
+// number of concurrent operations, and its limits can be changed at any time.
+type RateLimiter struct {
+	mu    sync.Mutex
+	bytes bucket
+	files bucket
+}
+
+// bucket is a token bucket holding one second worth of tokens, and at least one.
+type bucket struct {
+	rate   float64 // tokens per second; zero means unlimited
+	tokens float64
+	last   time.Time
+}
+
+// maxLimiterWait caps a single sleep so that limit changes take effect quickly.
+const maxLimiterWait = 100 * time.Millisecond
+
+// NewRateLimiter returns a limiter allowing bytesPerSec bytes and filesPerSec
+// files per second. A zero or negative limit disables that dimension.
+func NewRateLimiter(bytesPerSec, filesPerSec float64) *RateLimiter {
+	l := &RateLimiter{}
+	l.SetLimits(bytesPerSec, filesPerSec)
+	return l
+}
+
+// SetLimits changes both limits. Operations already waiting pick up the new
+// rates within a fraction of a second.
+func (l *RateLimiter) SetLimits(bytesPerSec, filesPerSec float64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	l.bytes.setRate(bytesPerSec, now)
+	l.files.setRate(filesPerSec, now)
+}
+
+// Limits returns the current byte and file rates.
+func (l *RateLimiter) Limits() (bytesPerSec, filesPerSec float64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.bytes.rate, l.files.rate
+}
+
+// WaitBytes blocks until n bytes may be transferred or ctx is cancelled.
+func (l *RateLimiter) WaitBytes(ctx context.Context, n int64) error {
+	return l.wait(ctx, &l.bytes, float64(n))
+}
+
+// WaitFile blocks until one more file may be processed or ctx is cancelled.
+func (l *RateLimiter) WaitFile(ctx context.Context) error {
+	return l.wait(ctx, &l.files, 1)
+}
+
+// wait takes n tokens from b, sleeping as needed. Requests larger than the
+// bucket are served in bucket-sized pieces.
+func (l *RateLimiter) wait(ctx context.Context, b *bucket, n float64) error {
+	for n > 0 {
+		l.mu.Lock()
+		now := time.Now()
+		b.refill(now)
+		if b.rate <= 0 {
+			l.mu.Unlock()
+			return ctx.Err()
+		}
+		need := n
+		if need > b.capacity() {
+			need = b.capacity()
+		}
+		if b.tokens >= need {
+			b.tokens -= need
+			n -= need
+			l.mu.Unlock()
+			continue
+		}
+		delay := time.Duration((need - b.tokens) / b.rate * float64(time.Second))
+		l.mu.Unlock()
+
+		if delay > maxLimiterWait {
+			delay = maxLimiterWait
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+	return ctx.Err()
+}
+
+// capacity returns the most tokens b can hold.
+func (b *bucket) capacity() float64 {
+	if b.rate < 1 {
+		return 1
+	}
+	return b.rate
+}
+
+// setRate changes the rate of b, keeping the tokens it has earned so far.
+// A new bucket starts full.
+func (b *bucket) setRate(rate float64, now time.Time) {
+	if rate < 0 {
+		rate = 0
+	}
+	if b.last.IsZero() {
+		b.rate, b.last = rate, now
+		b.tokens = b.capacity()
+		return
+	}
+	b.refill(now)
+	b.rate = rate
+	if b.tokens > b.capacity() {
+		b.tokens = b.capacity()
+	}
+}
+
+// refill adds the tokens earned since the last refill.
+func (b *bucket) refill(now time.Time) {
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.capacity() {
+		b.tokens = b.capacity()
+	}
+	b.last = now
+}
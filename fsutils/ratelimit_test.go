@@ -0,0 +1,58 @@
+package fsutils_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/utsav-56/go_fs_utils/fsutils"
+)
+
+func TestRateLimiter(t *testing.T) {
+	// Create a temporary directory for our tests
+	tempDir, err := os.MkdirTemp("", "fsutils-ratelimit-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	src := filepath.Join(tempDir, "src.bin")
+	if err := os.WriteFile(src, make([]byte, 300*1024), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	// Test a copy is throttled: 200 KiB come from the initial burst, the rest takes ~0.5s
+	limiter := fsutils.NewRateLimiter(200*1024, 0)
+	start := time.Now()
+	opts := fsutils.CopyOptions{Clone: fsutils.CloneForbid, RateLimit: limiter}
+	if _, err := fsutils.CopyFileWithOptions(src, filepath.Join(tempDir, "dst.bin"), opts); err != nil {
+		t.Fatalf("Failed to copy file: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Errorf("Rate limited copy finished too quickly: %v", elapsed)
+	}
+
+	// Test limits can be changed at runtime
+	limiter.SetLimits(0, 20)
+	if bytesPerSec, filesPerSec := limiter.Limits(); bytesPerSec != 0 || filesPerSec != 20 {
+		t.Errorf("Limits returned %v, %v after SetLimits", bytesPerSec, filesPerSec)
+	}
+	start = time.Now()
+	for i := 0; i < 30; i++ {
+		if err := limiter.WaitFile(context.Background()); err != nil {
+			t.Fatalf("WaitFile failed: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Errorf("File rate limit not applied: 30 files in %v", elapsed)
+	}
+
+	// Test waiting honours cancellation
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := limiter.WaitFile(ctx); err == nil {
+		t.Errorf("WaitFile ignored a cancelled context")
+	}
+}
@@ -0,0 +1,16 @@
+//go:build !unix
+
+// Package fsutils provides filesystem utility functions for Go applications.
+package fsutils
+
+import "os"
+
+// replaceEmptyDir renames the directory src over the empty directory dst.
+// This platform cannot replace a directory, so dst is removed first and the
+// name is briefly free.
+func replaceEmptyDir(src, dst string) error {
+	if err := os.Remove(dst); err != nil {
+		return err
+	}
+	return os.Rename(src, dst)
+}
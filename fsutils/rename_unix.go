@@ -0,0 +1,18 @@
+//go:build unix
+
+// Package fsutils provides filesystem utility functions for Go applications.
+package fsutils
+
+import (
+	"os"
+	"syscall"
+)
+
+// replaceEmptyDir renames the directory src over the empty directory dst in
+// one step. os.Rename refuses directory targets, but rename(2) allows them.
+func replaceEmptyDir(src, dst string) error {
+	if err := syscall.Rename(src, dst); err != nil {
+		return &os.LinkError{Op: "rename", Old: src, New: dst, Err: err}
+	}
+	return nil
+}
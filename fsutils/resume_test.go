@@ -0,0 +1,98 @@
+package fsutils_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/utsav-56/go_fs_utils/fsutils"
+)
+
+func TestCopyFileResumable(t *testing.T) {
+	// Create a temporary directory for our tests
+	tempDir, err := os.MkdirTemp("", "fsutils-resume-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	content := make([]byte, 10*1024)
+	for i := range content {
+		content[i] = byte(i % 251)
+	}
+	src := filepath.Join(tempDir, "big.bin")
+	dst := filepath.Join(tempDir, "big-copy.bin")
+	if err := os.WriteFile(src, content, 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	// Interrupt the copy after a few chunks
+	ctx, cancel := context.WithCancel(context.Background())
+	opts := fsutils.ResumeOptions{ChunkSize: 1024, Progress: func(p fsutils.Progress) {
+		if p.BytesDone >= 4096 {
+			cancel()
+		}
+	}}
+	if err := fsutils.CopyFileResumable(ctx, src, dst, opts); !errors.Is(err, context.Canceled) {
+		t.Fatalf("CopyFileResumable wasn't interrupted: %v", err)
+	}
+	if fsutils.FileExists(dst) {
+		t.Fatalf("CopyFileResumable created %s before finishing", dst)
+	}
+
+	// Corrupt the second chunk of the partial file
+	partial, err := os.OpenFile(dst+".partial", os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatalf("CopyFileResumable left no partial file: %v", err)
+	}
+	partial.WriteAt([]byte{0xff}, 1500)
+	partial.Close()
+
+	// Resume: only the first chunk survives verification
+	resumedFrom := int64(-1)
+	opts = fsutils.ResumeOptions{ChunkSize: 1024, Progress: func(p fsutils.Progress) {
+		if resumedFrom < 0 && p.BytesDone > 0 {
+			resumedFrom = p.BytesDone
+		}
+	}}
+	if err := fsutils.CopyFileResumable(context.Background(), src, dst, opts); err != nil {
+		t.Fatalf("CopyFileResumable failed to resume: %v", err)
+	}
+	if resumedFrom != 1024 {
+		t.Errorf("CopyFileResumable resumed from wrong offset: got %d, want %d", resumedFrom, 1024)
+	}
+	got, err := os.ReadFile(dst)
+	if err != nil || !bytes.Equal(got, content) {
+		t.Errorf("CopyFileResumable produced different content: %v", err)
+	}
+	if fsutils.FileExists(dst+".partial") || fsutils.FileExists(dst+".partial.json") {
+		t.Errorf("CopyFileResumable left its partial files behind")
+	}
+}
+
+func TestCopyFileResumableCheckpointError(t *testing.T) {
+	tempDir := t.TempDir()
+	src := filepath.Join(tempDir, "big.bin")
+	dst := filepath.Join(tempDir, "big-copy.bin")
+	if err := os.WriteFile(src, make([]byte, 4096), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	// A directory in the way of the checkpoint makes saving it fail
+	if err := os.Mkdir(dst+".partial.json", 0755); err != nil {
+		t.Fatalf("Failed to create test directory: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	opts := fsutils.ResumeOptions{ChunkSize: 1024, Progress: func(p fsutils.Progress) {
+		if p.BytesDone >= 2048 {
+			cancel()
+		}
+	}}
+	err := fsutils.CopyFileResumable(ctx, src, dst, opts)
+	if !errors.Is(err, context.Canceled) || err == context.Canceled {
+		t.Errorf("CopyFileResumable with a failing checkpoint returned %v, want both errors", err)
+	}
+}
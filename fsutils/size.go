@@ -0,0 +1,123 @@
+// Package fsutils provides filesystem utility functions for Go applications.
+package fsutils
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Size is a number of bytes. It formats itself in human-friendly units and
+// implements flag.Value, so command-line flags can accept sizes like "200M".
+type Size int64
+
+// Decimal (SI) and binary (IEC) size units.
+const (
+	Byte Size = 1
+
+	KB Size = 1000 * Byte
+	MB Size = 1000 * KB
+	GB Size = 1000 * MB
+	TB Size = 1000 * GB
+	PB Size = 1000 * TB
+	EB Size = 1000 * PB
+
+	KiB Size = 1024 * Byte
+	MiB Size = 1024 * KiB
+	GiB Size = 1024 * MiB
+	TiB Size = 1024 * GiB
+	PiB Size = 1024 * TiB
+	EiB Size = 1024 * PiB
+)
+
+// sizeUnits maps the lower-case unit suffixes accepted by ParseSize to their
+// values. Single letters are binary, as in du, dd and sort.
+var sizeUnits = map[string]Size{
+	"": Byte, "b": Byte,
+	"k": KiB, "kb": KB, "kib": KiB,
+	"m": MiB, "mb": MB, "mib": MiB,
+	"g": GiB, "gb": GB, "gib": GiB,
+	"t": TiB, "tb": TB, "tib": TiB,
+	"p": PiB, "pb": PB, "pib": PiB,
+	"e": EiB, "eb": EB, "eib": EiB,
+}
+
+// ParseSize parses a size such as "512", "200M", "1.5GiB" or "10 kB".
+// Units are case-insensitive: "KB", "MB" and so on are decimal, while
+// "KiB", "MiB" and the bare letters "K", "M", "G" are binary.
+func ParseSize(s string) (Size, error) {
+	text := strings.TrimSpace(s)
+	i := strings.IndexFunc(text, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if i < 0 {
+		i = len(text)
+	}
+	unit, ok := sizeUnits[strings.ToLower(strings.TrimSpace(text[i:]))]
+	if !ok {
+		return 0, fmt.Errorf("invalid size %q: unknown unit", s)
+	}
+	number := text[:i]
+	if n, err := strconv.ParseInt(number, 10, 64); err == nil {
+		if n > math.MaxInt64/int64(unit) {
+			return 0, fmt.Errorf("invalid size %q: too large", s)
+		}
+		return Size(n) * unit, nil
+	}
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	if value*float64(unit) >= math.MaxInt64 {
+		return 0, fmt.Errorf("invalid size %q: too large", s)
+	}
+	return Size(value * float64(unit)), nil
+}
+
+// String formats the size in binary units, such as "512 B" or "1.5 GiB".
+func (s Size) String() string {
+	return s.IEC()
+}
+
+// IEC formats the size in binary units: B, KiB, MiB, GiB and so on.
+func (s Size) IEC() string {
+	return s.format(1024, []string{"KiB", "MiB", "GiB", "TiB", "PiB", "EiB"})
+}
+
+// SI formats the size in decimal units: B, kB, MB, GB and so on.
+func (s Size) SI() string {
+	return s.format(1000, []string{"kB", "MB", "GB", "TB", "PB", "EB"})
+}
+
+// In returns the size as a multiple of unit, such as s.In(MiB).
+func (s Size) In(unit Size) float64 {
+	return float64(s) / float64(unit)
+}
+
+// format renders s with one decimal in the largest unit it reaches.
+func (s Size) format(base float64, units []string) string {
+	if s > -Size(base) && s < Size(base) {
+		return strconv.FormatInt(int64(s), 10) + " B"
+	}
+	value := float64(s)
+	unit := ""
+	for _, u := range units {
+		value /= base
+		unit = u
+		if value > -base && value < base {
+			break
+		}
+	}
+	return strings.TrimSuffix(strconv.FormatFloat(value, 'f', 1, 64), ".0") + " " + unit
+}
+
+// Set parses value into s, for use with flag.Var.
+func (s *Size) Set(value string) error {
+	parsed, err := ParseSize(value)
+	if err != nil {
+		return err
+	}
+	*s = parsed
+	return nil
+}
@@ -0,0 +1,106 @@
+package fsutils_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/utsav-56/go_fs_utils/fsutils"
+)
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		input string
+		want  fsutils.Size
+	}{
+		{"512", 512},
+		{"200M", 200 * fsutils.MiB},
+		{"1.5GiB", 1536 * fsutils.MiB},
+		{"10 kB", 10000},
+		{"2kb", 2 * fsutils.KB},
+		{"1TB", fsutils.TB},
+		{"0.5k", 512},
+	}
+	for _, tt := range tests {
+		got, err := fsutils.ParseSize(tt.input)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseSize(%q) = %d, %v, want %d", tt.input, got, err, tt.want)
+		}
+	}
+	for _, input := range []string{"", "12 parsecs", "-1M", "1.2.3K", "9999999E"} {
+		if _, err := fsutils.ParseSize(input); err == nil {
+			t.Errorf("ParseSize(%q) should fail", input)
+		}
+	}
+}
+
+func TestSizeFormat(t *testing.T) {
+	tests := []struct {
+		size    fsutils.Size
+		iec, si string
+	}{
+		{0, "0 B", "0 B"},
+		{999, "999 B", "999 B"},
+		{1536, "1.5 KiB", "1.5 kB"},
+		{4 * fsutils.MiB, "4 MiB", "4.2 MB"},
+		{3 * fsutils.TB, "2.7 TiB", "3 TB"},
+	}
+	for _, tt := range tests {
+		if got := tt.size.String(); got != tt.iec {
+			t.Errorf("Size(%d).String() = %q, want %q", tt.size, got, tt.iec)
+		}
+		if got := tt.size.SI(); got != tt.si {
+			t.Errorf("Size(%d).SI() = %q, want %q", tt.size, got, tt.si)
+		}
+	}
+	if got := (3 * fsutils.MiB).In(fsutils.KiB); got != 3072 {
+		t.Errorf("In(KiB) = %v, want 3072", got)
+	}
+}
+
+func TestCopyQuota(t *testing.T) {
+	// Create a temporary directory for our tests
+	tempDir, err := os.MkdirTemp("", "fsutils-quota-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	src := filepath.Join(tempDir, "src")
+	fsutils.Mkdir(src)
+	for _, name := range []string{"a", "b", "c"} {
+		os.WriteFile(filepath.Join(src, name), make([]byte, 1000), 0644)
+	}
+
+	// Test a pre-scanned copy that doesn't fit fails up front
+	opts := fsutils.CopyOptions{Quota: 2 * fsutils.KB, PreScan: true}
+	_, err = fsutils.CopyDirWithOptions(src, filepath.Join(tempDir, "scanned"), opts)
+	if !errors.Is(err, fsutils.ErrQuotaExceeded) {
+		t.Errorf("CopyDir over quota returned %v", err)
+	}
+	if fsutils.DirExists(filepath.Join(tempDir, "scanned")) {
+		t.Error("Pre-scanned copy over quota created the destination")
+	}
+
+	// Test the quota stops an unscanned copy once it is used up
+	opts = fsutils.CopyOptions{Quota: 2 * fsutils.KB, ContinueOnError: true}
+	result, err := fsutils.CopyDirWithOptions(src, filepath.Join(tempDir, "dst"), opts)
+	if !errors.Is(err, fsutils.ErrQuotaExceeded) || result.Files != 2 || result.Failed != 1 {
+		t.Errorf("CopyDir over quota = %+v, %v", result, err)
+	}
+
+	// Test walk size filters
+	os.WriteFile(filepath.Join(src, "big"), make([]byte, 5000), 0644)
+	var matches []string
+	err = fsutils.Walk(context.Background(), src, fsutils.WalkOptions{MinSize: 4 * fsutils.KB}, func(entry fsutils.WalkEntry) error {
+		if !entry.Info.IsDir() {
+			matches = append(matches, entry.Path)
+		}
+		return nil
+	})
+	if err != nil || len(matches) != 1 || filepath.Base(matches[0]) != "big" {
+		t.Errorf("Walk with MinSize found %v, %v", matches, err)
+	}
+}
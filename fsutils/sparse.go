@@ -0,0 +1,48 @@
+// Package fsutils provides filesystem utility functions for Go applications.
+package fsutils
+
+import (
+	"io"
+	"os"
+)
+
+// segment is a half-open byte range [start, end) of a file holding data.
+type segment struct {
+	start, end int64
+}
+
+// copySparse copies the data segments of src into dst at the same offsets and
+// extends dst to size, so the gaps between segments stay unallocated holes.
+func copySparse(dst, src *os.File, segments []segment, size int64, t *tracker) (int64, error) {
+	var written int64
+	buf := make([]byte, copyBufferSize)
+	for _, seg := range segments {
+		if _, err := src.Seek(seg.start, io.SeekStart); err != nil {
+			return written, err
+		}
+		if _, err := dst.Seek(seg.start, io.SeekStart); err != nil {
+			return written, err
+		}
+		n, err := io.CopyBuffer(progressWriter{dst, t}, io.LimitReader(src, seg.end-seg.start), buf)
+		written += n
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, dst.Truncate(size)
+}
+
+// AllocatedSize returns the number of bytes actually allocated on disk for the
+// file at path along with its apparent size. Sparse files allocate less than
+// their apparent size; on platforms without block counts both values match.
+func AllocatedSize(path string) (allocated, apparent int64, err error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	allocated, ok := fileAllocated(info)
+	if !ok {
+		allocated = info.Size()
+	}
+	return allocated, info.Size(), nil
+}
@@ -0,0 +1,141 @@
+//go:build linux
+
+// Package fsutils provides filesystem utility functions for Go applications.
+package fsutils
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"syscall"
+)
+
+// lseek whence values and fallocate flags from <linux/fs.h> and <linux/falloc.h>.
+const (
+	seekData           = 3
+	seekHole           = 4
+	fallocKeepSize     = 0x01
+	fallocPunchHole    = 0x02
+	punchHoleBlockSize = 4096
+)
+
+// dataSegments lists the data regions of a sparse file using SEEK_DATA and SEEK_HOLE.
+// ok is false when f is not sparse or the filesystem cannot report holes.
+func dataSegments(f *os.File, size int64) (segments []segment, ok bool) {
+	info, err := f.Stat()
+	if err != nil || !info.Mode().IsRegular() {
+		return nil, false
+	}
+	if allocated, ok := fileAllocated(info); !ok || allocated >= size {
+		return nil, false
+	}
+	segments, err = seekSegments(f, size)
+	if err != nil {
+		return nil, false
+	}
+	return segments, true
+}
+
+// seekSegments walks f with SEEK_DATA/SEEK_HOLE up to size.
+func seekSegments(f *os.File, size int64) ([]segment, error) {
+	var segments []segment
+	for off := int64(0); off < size; {
+		start, err := f.Seek(off, seekData)
+		if err != nil {
+			if pe, ok := err.(*fs.PathError); ok && pe.Err == syscall.ENXIO {
+				break // only a hole remains
+			}
+			return nil, err
+		}
+		end, err := f.Seek(start, seekHole)
+		if err != nil {
+			return nil, err
+		}
+		if end > size {
+			end = size
+		}
+		segments = append(segments, segment{start, end})
+		off = end
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return segments, nil
+}
+
+// PunchHoles deallocates the all-zero blocks of the file at path, turning them
+// into holes without changing its contents or apparent size.
+// Returns the number of bytes released. Filesystems that cannot punch holes
+// fail with an error wrapping ErrUnsupported.
+func PunchHoles(path string) (int64, error) {
+	file, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return 0, err
+	}
+	segments, err := seekSegments(file, info.Size())
+	if err != nil {
+		return 0, err
+	}
+
+	var punched int64
+	buf := make([]byte, punchHoleBlockSize)
+	for _, seg := range segments {
+		// Only whole, aligned blocks can be released.
+		off := (seg.start + punchHoleBlockSize - 1) / punchHoleBlockSize * punchHoleBlockSize
+		runStart := int64(-1)
+		for ; off+punchHoleBlockSize <= seg.end; off += punchHoleBlockSize {
+			if _, err := file.ReadAt(buf, off); err != nil {
+				return punched, err
+			}
+			if isZero(buf) {
+				if runStart < 0 {
+					runStart = off
+				}
+				continue
+			}
+			if runStart >= 0 {
+				if err := punchHole(file, runStart, off-runStart); err != nil {
+					return punched, err
+				}
+				punched += off - runStart
+				runStart = -1
+			}
+		}
+		if runStart >= 0 {
+			if err := punchHole(file, runStart, off-runStart); err != nil {
+				return punched, err
+			}
+			punched += off - runStart
+		}
+	}
+	return punched, nil
+}
+
+// isZero reports whether buf contains only zero bytes.
+func isZero(buf []byte) bool {
+	for _, b := range buf {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// punchHole deallocates length bytes of f starting at off. Filesystems that
+// cannot punch holes fail with ErrUnsupported.
+func punchHole(f *os.File, off, length int64) error {
+	err := syscall.Fallocate(int(f.Fd()), fallocPunchHole|fallocKeepSize, off, length)
+	switch err {
+	case nil:
+		return nil
+	case syscall.EOPNOTSUPP, syscall.ENOSYS:
+		return &os.PathError{Op: "fallocate", Path: f.Name(), Err: ErrUnsupported}
+	}
+	return &os.PathError{Op: "fallocate", Path: f.Name(), Err: err}
+}
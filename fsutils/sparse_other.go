@@ -0,0 +1,16 @@
+//go:build !linux
+
+// Package fsutils provides filesystem utility functions for Go applications.
+package fsutils
+
+import "os"
+
+// dataSegments cannot detect holes on this platform, so files are copied densely.
+func dataSegments(f *os.File, size int64) (segments []segment, ok bool) {
+	return nil, false
+}
+
+// PunchHoles is not supported on this platform.
+func PunchHoles(path string) (int64, error) {
+	return 0, &os.PathError{Op: "punchholes", Path: path, Err: ErrUnsupported}
+}
@@ -0,0 +1,74 @@
+package fsutils_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/utsav-56/go_fs_utils/fsutils"
+)
+
+func TestSparseCopy(t *testing.T) {
+	// Create a temporary directory for our tests
+	tempDir, err := os.MkdirTemp("", "fsutils-sparse-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	// Create a 64 MiB file with data only at the start and the end
+	src := filepath.Join(tempDir, "disk.img")
+	file, err := os.Create(src)
+	if err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	const size = 64 << 20
+	file.WriteAt([]byte("header"), 0)
+	file.WriteAt([]byte("trailer"), size-7)
+	file.Close()
+
+	srcAllocated, apparent, err := fsutils.AllocatedSize(src)
+	if err != nil {
+		t.Fatalf("AllocatedSize failed: %v", err)
+	}
+	if apparent != size {
+		t.Errorf("AllocatedSize returned wrong apparent size: got %d, want %d", apparent, size)
+	}
+
+	// Test CopyFile keeps the holes
+	dst := filepath.Join(tempDir, "copy.img")
+	if err := fsutils.CopyFile(src, dst); err != nil {
+		t.Fatalf("Failed to copy file: %v", err)
+	}
+	want, _ := os.ReadFile(src)
+	got, _ := os.ReadFile(dst)
+	if !bytes.Equal(got, want) {
+		t.Fatalf("CopyFile changed the content of a sparse file")
+	}
+	dstAllocated, _, _ := fsutils.AllocatedSize(dst)
+	if srcAllocated < size && dstAllocated >= size {
+		t.Errorf("CopyFile filled the holes: %d bytes allocated", dstAllocated)
+	}
+
+	// Test PunchHoles releases zeroed blocks of a dense file
+	dense := filepath.Join(tempDir, "dense.img")
+	if err := os.WriteFile(dense, make([]byte, 1<<20), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	punched, err := fsutils.PunchHoles(dense)
+	if errors.Is(err, fsutils.ErrUnsupported) {
+		t.Skip("PunchHoles is not supported on this platform")
+	}
+	if err != nil {
+		t.Fatalf("PunchHoles failed: %v", err)
+	}
+	if punched != 1<<20 {
+		t.Errorf("PunchHoles released wrong amount: got %d, want %d", punched, 1<<20)
+	}
+	allocated, apparent, _ := fsutils.AllocatedSize(dense)
+	if apparent != 1<<20 || allocated >= apparent {
+		t.Errorf("PunchHoles left %d of %d bytes allocated", allocated, apparent)
+	}
+}
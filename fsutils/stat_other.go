@@ -0,0 +1,26 @@
+//go:build !unix
+
+// Package fsutils provides filesystem utility functions for Go applications.
+package fsutils
+
+import "io/fs"
+
+// fileDevIno is not implemented on this platform.
+func fileDevIno(info fs.FileInfo) (dev, ino uint64, ok bool) {
+	return 0, 0, false
+}
+
+// fileLinkCount is not implemented on this platform.
+func fileLinkCount(info fs.FileInfo) (uint64, bool) {
+	return 0, false
+}
+
+// fileOwner is not implemented on this platform.
+func fileOwner(info fs.FileInfo) (uid, gid int, ok bool) {
+	return 0, 0, false
+}
+
+// fileAllocated is not implemented on this platform.
+func fileAllocated(info fs.FileInfo) (int64, bool) {
+	return 0, false
+}
@@ -0,0 +1,45 @@
+//go:build unix
+
+// Package fsutils provides filesystem utility functions for Go applications.
+package fsutils
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// fileDevIno returns the device and inode numbers backing info.
+func fileDevIno(info fs.FileInfo) (dev, ino uint64, ok bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, 0, false
+	}
+	return uint64(st.Dev), uint64(st.Ino), true
+}
+
+// fileLinkCount returns the number of hardlinks to info.
+func fileLinkCount(info fs.FileInfo) (uint64, bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+	return uint64(st.Nlink), true
+}
+
+// fileOwner returns the numeric owner and group of info.
+func fileOwner(info fs.FileInfo) (uid, gid int, ok bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, 0, false
+	}
+	return int(st.Uid), int(st.Gid), true
+}
+
+// fileAllocated returns the bytes allocated on disk for info.
+func fileAllocated(info fs.FileInfo) (int64, bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+	return int64(st.Blocks) * 512, true
+}
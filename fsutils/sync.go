@@ -0,0 +1,210 @@
+// Package fsutils provides filesystem utility functions for Go applications.
+package fsutils
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SyncOptions controls the behaviour of SyncDir.
+type SyncOptions struct {
+	// Delete removes entries from the destination that are not in the source.
+	Delete bool
+	// Checksum compares file contents instead of sizes and modification
+	// times to decide what needs copying.
+	Checksum bool
+	// DryRun reports what would be copied and deleted without changing anything.
+	DryRun bool
+	// Progress, if set, receives progress updates while files are copied.
+	Progress ProgressFunc
+	// RateLimit, if set, throttles the bytes and files copied per second.
+	RateLimit *RateLimiter
+	// ContinueOnError makes SyncDir skip paths that cannot be synchronised
+	// and carry on. The failures are returned together as a *MultiError.
+	ContinueOnError bool
+	// Ignore and IgnoreFile leave paths out of the sync, as they do for
+	// Walk. The destination is judged by the ignore files of the source,
+	// and ignored paths in it are never deleted.
+	Ignore     *IgnoreMatcher
+	IgnoreFile string
+	// Guard adjusts the CheckRemovable checks made on the destination and
+	// on every path Delete removes from it.
+	Guard GuardOptions
+}
+
+// SyncResult describes what SyncDir changed, or would change in a dry run.
+type SyncResult struct {
+	Copied  []string // Slash-separated paths created or updated in the destination
+	Deleted []string // Slash-separated paths removed from the destination
+	Bytes   int64    // Number of bytes copied
+}
+
+// SyncDir makes the tree at dst mirror the tree at src, creating dst if
+// needed. Only entries that DiffDirs reports as different are copied, and
+// copied files keep the source's permissions and modification time so the
+// next run sees them as unchanged. Entries missing from src are left alone
+// unless opts.Delete is set, and even then only if CheckRemovable accepts
+// both dst and them with opts.Guard. It stops with ctx.Err() once ctx is
+// cancelled.
+func SyncDir(ctx context.Context, src, dst string, opts SyncOptions) (SyncResult, error) {
+	var result SyncResult
+	if opts.Delete {
+		// Deleting may empty dst, so it must be safe to remove as a whole.
+		if err := CheckRemovable(dst, opts.Guard); err != nil {
+			return result, err
+		}
+	}
+	walkOpts := WalkOptions{Ignore: opts.Ignore, IgnoreFile: opts.IgnoreFile}
+	want, err := treeSnapshot(ctx, src, walkOpts)
+	if err != nil {
+		return result, err
+	}
+	if opts.IgnoreFile != "" {
+		// Judge dst by the ignore files of src, which it may not have yet.
+		if walkOpts.Ignore, err = treeIgnores(src, want, opts.Ignore, opts.IgnoreFile); err != nil {
+			return result, err
+		}
+		walkOpts.IgnoreFile = ""
+	}
+	have, err := treeSnapshot(ctx, dst, walkOpts)
+	if os.IsNotExist(err) {
+		have = make(map[string]fs.FileInfo)
+		if !opts.DryRun {
+			err = os.MkdirAll(dst, 0755)
+		} else {
+			err = nil
+		}
+	}
+	if err != nil {
+		return result, err
+	}
+	diffs, err := diffTrees(ctx, dst, src, have, want, DiffOptions{Checksum: opts.Checksum})
+	if err != nil {
+		return result, err
+	}
+
+	t := newTracker(ctx, opts.Progress)
+	t.limit = opts.RateLimit
+	for _, d := range diffs {
+		if info := want[d.Path]; d.Kind != DiffRemoved {
+			t.progress.FilesTotal++
+			if info.Mode().IsRegular() {
+				t.progress.BytesTotal += info.Size()
+			}
+		}
+	}
+
+	errs := newErrorList("sync", opts.ContinueOnError)
+	removed := "" // last directory removed from dst, whose entries are gone too
+	for _, d := range diffs {
+		if removed != "" && strings.HasPrefix(d.Path, removed+"/") {
+			continue
+		}
+		target := filepath.Join(dst, filepath.FromSlash(d.Path))
+		if d.Kind == DiffRemoved || d.Kind == DiffTypeChanged {
+			if d.Kind == DiffRemoved && !opts.Delete {
+				continue
+			}
+			if err := CheckRemovable(target, opts.Guard); err != nil {
+				if err = errs.record(target, err); err == errSkipped {
+					continue
+				}
+				return result, err
+			}
+			if !opts.DryRun {
+				if err := os.RemoveAll(target); err != nil {
+					if err = errs.record(target, err); err == errSkipped {
+						continue
+					}
+					return result, err
+				}
+			}
+			if have[d.Path].IsDir() {
+				removed = d.Path
+			}
+			if d.Kind == DiffRemoved {
+				result.Deleted = append(result.Deleted, d.Path)
+				continue
+			}
+		}
+		if !opts.DryRun {
+			source := filepath.Join(src, filepath.FromSlash(d.Path))
+			n, err := syncEntry(source, target, want[d.Path], t)
+			result.Bytes += n
+			if errs.skipVanished(source, err) {
+				continue
+			}
+			if err != nil && ctx.Err() == nil {
+				err = errs.record(target, err)
+			}
+			if err == errSkipped {
+				continue
+			}
+			if err != nil {
+				return result, err
+			}
+		}
+		result.Copied = append(result.Copied, d.Path)
+	}
+	return result, errs.err(len(result.Copied) + len(result.Deleted))
+}
+
+// copySymlink replaces dst with a symlink to the target of the symlink src.
+func copySymlink(src, dst string, t *tracker) error {
+	if err := t.start(src); err != nil {
+		return err
+	}
+	target, err := os.Readlink(src)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(dst); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.Symlink(target, dst); err != nil {
+		return err
+	}
+	return t.addFile()
+}
+
+// syncEntry creates or replaces dst with a copy of the entry src described
+// by info, returning the number of bytes copied.
+func syncEntry(src, dst string, info fs.FileInfo, t *tracker) (int64, error) {
+	switch {
+	case info.IsDir():
+		if err := t.start(src); err != nil {
+			return 0, err
+		}
+		if err := os.Mkdir(dst, info.Mode().Perm()); err != nil && !os.IsExist(err) {
+			return 0, err
+		}
+		return 0, t.addFile()
+	case info.Mode()&fs.ModeSymlink != 0:
+		return 0, copySymlink(src, dst, t)
+	case info.Mode().IsRegular():
+		// The copy goes to a temporary file renamed over dst, which works even
+		// if dst is read-only and never leaves it half written.
+		tmp := filepath.Join(filepath.Dir(dst), "."+filepath.Base(dst)+".fsutils-sync")
+		os.Remove(tmp)
+		result, err := copyFile(src, tmp, CopyOptions{RateLimit: t.limit}, t)
+		if err == nil {
+			err = os.Chmod(tmp, info.Mode().Perm())
+		}
+		if err == nil {
+			err = os.Chtimes(tmp, info.ModTime(), info.ModTime())
+		}
+		if err == nil {
+			err = os.Rename(tmp, dst)
+		}
+		if err != nil {
+			os.Remove(tmp)
+		}
+		return result.Bytes, err
+	default:
+		return 0, fmt.Errorf("'%s' is not a regular file, directory or symlink", src)
+	}
+}
@@ -0,0 +1,107 @@
+package fsutils_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/utsav-56/go_fs_utils/fsutils"
+)
+
+func TestSyncDir(t *testing.T) {
+	// Create a temporary directory for our tests
+	tempDir, err := os.MkdirTemp("", "fsutils-sync-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	src := filepath.Join(tempDir, "src")
+	dst := filepath.Join(tempDir, "dst")
+	fsutils.Mkdir(filepath.Join(src, "sub"))
+	os.WriteFile(filepath.Join(src, "keep.txt"), []byte("keep"), 0644)
+	os.WriteFile(filepath.Join(src, "sub", "run.sh"), []byte("#!/bin/sh"), 0755)
+	ctx := context.Background()
+
+	// Test the first sync copies everything
+	result, err := fsutils.SyncDir(ctx, src, dst, fsutils.SyncOptions{})
+	if err != nil {
+		t.Fatalf("SyncDir failed: %v", err)
+	}
+	want := []string{"keep.txt", "sub", "sub/run.sh"}
+	if !reflect.DeepEqual(result.Copied, want) {
+		t.Errorf("SyncDir copied %v, want %v", result.Copied, want)
+	}
+	if info, err := os.Stat(filepath.Join(dst, "sub", "run.sh")); err != nil || info.Mode().Perm() != 0755 {
+		t.Errorf("SyncDir didn't keep permissions: %v %v", info, err)
+	}
+	diffs, err := fsutils.DiffDirs(ctx, src, dst, fsutils.DiffOptions{})
+	if err != nil || len(diffs) != 0 {
+		t.Errorf("Trees differ after sync: %v %v", diffs, err)
+	}
+
+	// Test changes in both trees
+	os.WriteFile(filepath.Join(src, "keep.txt"), []byte("changed"), 0644)
+	os.WriteFile(filepath.Join(dst, "extra.txt"), []byte("extra"), 0644)
+	diffs, err = fsutils.DiffDirs(ctx, src, dst, fsutils.DiffOptions{Checksum: true})
+	if err != nil {
+		t.Fatalf("DiffDirs failed: %v", err)
+	}
+	wantDiffs := []fsutils.DiffEntry{
+		{Path: "extra.txt", Kind: fsutils.DiffAdded},
+		{Path: "keep.txt", Kind: fsutils.DiffModified},
+	}
+	if !reflect.DeepEqual(diffs, wantDiffs) {
+		t.Errorf("DiffDirs = %v, want %v", diffs, wantDiffs)
+	}
+
+	// Test a dry run changes nothing
+	opts := fsutils.SyncOptions{Delete: true, DryRun: true}
+	result, err = fsutils.SyncDir(ctx, src, dst, opts)
+	if err != nil {
+		t.Fatalf("SyncDir dry run failed: %v", err)
+	}
+	if !reflect.DeepEqual(result.Copied, []string{"keep.txt"}) || !reflect.DeepEqual(result.Deleted, []string{"extra.txt"}) {
+		t.Errorf("SyncDir dry run = %+v", result)
+	}
+	if !fsutils.FileExists(filepath.Join(dst, "extra.txt")) {
+		t.Error("SyncDir dry run deleted a file")
+	}
+
+	// Test Delete refuses a destination the guard would not let it remove
+	guarded := fsutils.SyncOptions{Delete: true, Guard: fsutils.GuardOptions{AllowedRoots: []string{dst}}}
+	if _, err := fsutils.SyncDir(ctx, src, dst, guarded); !errors.Is(err, fsutils.ErrUnsafePath) {
+		t.Errorf("SyncDir into a guarded destination returned %v, want ErrUnsafePath", err)
+	}
+	if !fsutils.FileExists(filepath.Join(dst, "extra.txt")) {
+		t.Error("SyncDir deleted from a guarded destination")
+	}
+
+	// Test delete
+	opts.DryRun = false
+	if _, err := fsutils.SyncDir(ctx, src, dst, opts); err != nil {
+		t.Fatalf("SyncDir failed: %v", err)
+	}
+	if fsutils.FileExists(filepath.Join(dst, "extra.txt")) {
+		t.Error("SyncDir with Delete kept an extra file")
+	}
+	if data, _ := os.ReadFile(filepath.Join(dst, "keep.txt")); string(data) != "changed" {
+		t.Errorf("SyncDir didn't update file: %q", data)
+	}
+
+	// Test a read-only destination file is replaced
+	os.Chmod(filepath.Join(dst, "keep.txt"), 0444)
+	os.WriteFile(filepath.Join(src, "keep.txt"), []byte("read-only"), 0644)
+	if _, err := fsutils.SyncDir(ctx, src, dst, fsutils.SyncOptions{}); err != nil {
+		t.Fatalf("SyncDir over a read-only file failed: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dst, "keep.txt")); string(data) != "read-only" {
+		t.Errorf("SyncDir didn't update a read-only file: %q", data)
+	}
+	if entries, _ := os.ReadDir(dst); len(entries) != 2 {
+		t.Errorf("SyncDir left temporary files behind: %v", entries)
+	}
+}
@@ -0,0 +1,67 @@
+//go:build linux
+
+// Package fsutils provides filesystem utility functions for Go applications.
+package fsutils
+
+import (
+	"bufio"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// ficlone is the FICLONE ioctl request number from <linux/fs.h>.
+const ficlone = 0x40049409
+
+// reflink makes dst share the data extents of src using the FICLONE ioctl.
+// It only succeeds on copy-on-write filesystems such as btrfs and XFS.
+func reflink(src, dst *os.File) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, dst.Fd(), ficlone, src.Fd())
+	if errno != 0 {
+		return &os.PathError{Op: "ficlone", Path: dst.Name(), Err: errno}
+	}
+	return nil
+}
+
+// trashSupported reports whether MoveToTrash and ListTrash work here.
+const trashSupported = true
+
+// mountPoints returns the mount points listed in /proc/self/mounts.
+func mountPoints() []string {
+	file, err := os.Open("/proc/self/mounts")
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+	var points []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		points = append(points, unescapeMount(fields[1]))
+	}
+	return points
+}
+
+// unescapeMount decodes the octal escapes, such as "\040" for a space, of a
+// field in /proc/self/mounts.
+func unescapeMount(field string) string {
+	if !strings.Contains(field, `\`) {
+		return field
+	}
+	var b strings.Builder
+	for i := 0; i < len(field); i++ {
+		if field[i] == '\\' && i+3 < len(field) {
+			if c, err := strconv.ParseUint(field[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(c))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(field[i])
+	}
+	return b.String()
+}
@@ -0,0 +1,19 @@
+//go:build !linux
+
+// Package fsutils provides filesystem utility functions for Go applications.
+package fsutils
+
+import "os"
+
+// reflink is not supported on this platform.
+func reflink(src, dst *os.File) error {
+	return &os.PathError{Op: "ficlone", Path: dst.Name(), Err: ErrUnsupported}
+}
+
+// trashSupported reports whether MoveToTrash and ListTrash work here.
+const trashSupported = false
+
+// mountPoints is not implemented on this platform.
+func mountPoints() []string {
+	return nil
+}
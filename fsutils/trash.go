@@ -0,0 +1,480 @@
+// Package fsutils provides filesystem utility functions for Go applications.
+package fsutils
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// trashDateLayout is the DeletionDate format of .trashinfo files, in local
+// time as the freedesktop.org Trash specification requires.
+const trashDateLayout = "2006-01-02T15:04:05"
+
+// TrashItem is a file or directory in a trash can.
+type TrashItem struct {
+	Name         string    `json:"name"`         // Name in the trash can, unique within it
+	OriginalPath string    `json:"originalPath"` // Absolute path the item was deleted from
+	DeletionDate time.Time `json:"deletionDate"`
+	Size         int64     `json:"size"` // Apparent size, of the whole tree for a directory
+	IsDir        bool      `json:"isDir"`
+	TrashDir     string    `json:"trashDir"` // Trash can holding the item
+}
+
+// path returns where the item's data is kept.
+func (item TrashItem) path() string {
+	return filepath.Join(item.TrashDir, "files", item.Name)
+}
+
+// infoPath returns the path of the item's .trashinfo file.
+func (item TrashItem) infoPath() string {
+	return filepath.Join(item.TrashDir, "info", item.Name+".trashinfo")
+}
+
+// MoveToTrash moves path to the trash following the freedesktop.org Trash
+// specification, as file managers do, so it can be restored later with
+// RestoreFromTrash. Paths on the same filesystem as the home directory go
+// to the home trash, $XDG_DATA_HOME/Trash; others go to the trash at the top
+// of their own filesystem, .Trash/$uid if an administrator has set up
+// .Trash, and .Trash-$uid otherwise. Nothing is ever copied between
+// filesystems. It returns ErrUnsupported on systems other than Linux.
+func MoveToTrash(path string) (TrashItem, error) {
+	if !trashSupported {
+		return TrashItem{}, &os.PathError{Op: "trash", Path: path, Err: ErrUnsupported}
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return TrashItem{}, err
+	}
+	info, err := os.Lstat(abs)
+	if err != nil {
+		return TrashItem{}, err
+	}
+	trashDir, topDir, err := trashFor(abs)
+	if err != nil {
+		return TrashItem{}, err
+	}
+	for _, dir := range []string{"files", "info"} {
+		if err := os.MkdirAll(filepath.Join(trashDir, dir), 0700); err != nil {
+			return TrashItem{}, err
+		}
+	}
+
+	item := TrashItem{OriginalPath: abs, DeletionDate: time.Now().Truncate(time.Second), IsDir: info.IsDir(), TrashDir: trashDir}
+	recorded := abs
+	if topDir != "" {
+		if rel, err := filepath.Rel(topDir, abs); err == nil {
+			recorded = rel
+		}
+	}
+	content := fmt.Sprintf("[Trash Info]\nPath=%s\nDeletionDate=%s\n", escapeTrashPath(recorded), item.DeletionDate.Format(trashDateLayout))
+
+	// The .trashinfo file is created first, with O_EXCL, to claim the name.
+	err = reserveUnique(filepath.Join(trashDir, "files", filepath.Base(abs)), TemplateDashed, func(name string) error {
+		item.Name = filepath.Base(name)
+		if _, err := os.Lstat(item.path()); err == nil {
+			return &os.PathError{Op: "trash", Path: item.path(), Err: os.ErrExist}
+		}
+		file, err := os.OpenFile(item.infoPath(), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if err != nil {
+			return err
+		}
+		_, err = file.WriteString(content)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		return err
+	})
+	if err != nil {
+		return TrashItem{}, err
+	}
+	if err := os.Rename(abs, item.path()); err != nil {
+		os.Remove(item.infoPath())
+		return TrashItem{}, err
+	}
+	item.Size = trashItemSize(item.path())
+	return item, nil
+}
+
+// trashFor returns the trash can for the absolute path abs, and the top
+// directory of its filesystem if that is not the home trash.
+func trashFor(abs string) (trashDir, topDir string, err error) {
+	home, err := homeTrashDir()
+	if err != nil {
+		return "", "", err
+	}
+	if sameDevice(filepath.Dir(abs), existingAncestor(home)) {
+		return home, "", nil
+	}
+	topDir = mountPoint(abs)
+	uid := strconv.Itoa(os.Getuid())
+
+	// An administrator-provided .Trash must be a real sticky directory.
+	shared := filepath.Join(topDir, ".Trash")
+	if info, err := os.Lstat(shared); err == nil && info.IsDir() && info.Mode()&os.ModeSticky != 0 {
+		dir := filepath.Join(shared, uid)
+		if err := os.Mkdir(dir, 0700); err == nil || os.IsExist(err) {
+			if ownTrashDir(dir) {
+				return dir, topDir, nil
+			}
+		}
+	}
+	dir := filepath.Join(topDir, ".Trash-"+uid)
+	if err := os.Mkdir(dir, 0700); err != nil && !os.IsExist(err) {
+		return "", "", err
+	}
+	if !ownTrashDir(dir) {
+		return "", "", &os.PathError{Op: "trash", Path: dir, Err: fmt.Errorf("not a directory owned by the current user")}
+	}
+	return dir, topDir, nil
+}
+
+// homeTrashDir returns the home trash, $XDG_DATA_HOME/Trash, where
+// XDG_DATA_HOME defaults to ~/.local/share.
+func homeTrashDir() (string, error) {
+	data := os.Getenv("XDG_DATA_HOME")
+	if data == "" || !filepath.IsAbs(data) {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		data = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(data, "Trash"), nil
+}
+
+// ownTrashDir reports whether dir is a directory, not a symlink, that
+// belongs to the current user.
+func ownTrashDir(dir string) bool {
+	info, err := os.Lstat(dir)
+	if err != nil || !info.IsDir() {
+		return false
+	}
+	uid, _, ok := fileOwner(info)
+	return !ok || uid == os.Getuid()
+}
+
+// existingAncestor returns path or its closest ancestor that exists.
+func existingAncestor(path string) string {
+	for {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return path
+		}
+		path = parent
+	}
+}
+
+// sameDevice reports whether a and b are on the same filesystem. It
+// assumes they are when the platform does not say.
+func sameDevice(a, b string) bool {
+	infoA, errA := os.Stat(a)
+	infoB, errB := os.Stat(b)
+	if errA != nil || errB != nil {
+		return false
+	}
+	devA, _, okA := fileDevIno(infoA)
+	devB, _, okB := fileDevIno(infoB)
+	return !okA || !okB || devA == devB
+}
+
+// mountPoint returns the top directory of the filesystem holding abs: its
+// highest ancestor on the same device.
+func mountPoint(abs string) string {
+	dir := filepath.Dir(abs)
+	for {
+		parent := filepath.Dir(dir)
+		if parent == dir || !sameDevice(dir, parent) {
+			return dir
+		}
+		dir = parent
+	}
+}
+
+// trashDirs returns the trash cans of the current user that exist: the home
+// trash and those at the top of every mounted filesystem.
+func trashDirs() ([]string, error) {
+	home, err := homeTrashDir()
+	if err != nil {
+		return nil, err
+	}
+	dirs := []string{home}
+	seen := map[string]bool{home: true}
+	uid := strconv.Itoa(os.Getuid())
+	for _, top := range mountPoints() {
+		for _, dir := range []string{filepath.Join(top, ".Trash", uid), filepath.Join(top, ".Trash-"+uid)} {
+			if !seen[dir] && ownTrashDir(dir) {
+				seen[dir] = true
+				dirs = append(dirs, dir)
+			}
+		}
+	}
+	return dirs, nil
+}
+
+// ListTrash returns the items in the trash cans of the current user, oldest
+// deletion first. Entries without valid .trashinfo files are left out, as
+// the specification asks.
+func ListTrash() ([]TrashItem, error) {
+	if !trashSupported {
+		return nil, ErrUnsupported
+	}
+	dirs, err := trashDirs()
+	if err != nil {
+		return nil, err
+	}
+	var items []TrashItem
+	for _, dir := range dirs {
+		found, err := listTrashDir(dir)
+		if err != nil {
+			return items, err
+		}
+		items = append(items, found...)
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].DeletionDate.Before(items[j].DeletionDate)
+	})
+	return items, nil
+}
+
+// listTrashDir reads the items of one trash can.
+func listTrashDir(dir string) ([]TrashItem, error) {
+	entries, err := os.ReadDir(filepath.Join(dir, "info"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	// A volume trash records paths relative to the top of its filesystem.
+	topDir := filepath.Dir(dir)
+	if filepath.Base(topDir) == ".Trash" {
+		topDir = filepath.Dir(topDir)
+	}
+	var items []TrashItem
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), ".trashinfo")
+		if name == entry.Name() {
+			continue
+		}
+		item := TrashItem{Name: name, TrashDir: dir}
+		if err := readTrashInfo(&item, topDir); err != nil {
+			continue
+		}
+		info, err := os.Lstat(item.path())
+		if err != nil {
+			continue
+		}
+		item.IsDir = info.IsDir()
+		item.Size = trashItemSize(item.path())
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// readTrashInfo fills in the original path and deletion date of item from
+// its .trashinfo file.
+func readTrashInfo(item *TrashItem, topDir string) error {
+	file, err := os.Open(item.infoPath())
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	var path, date string
+	inGroup := false
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			inGroup = line == "[Trash Info]"
+			continue
+		}
+		if key, value, ok := strings.Cut(line, "="); ok && inGroup {
+			switch key {
+			case "Path":
+				path = value
+			case "DeletionDate":
+				date = value
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if path == "" {
+		return fmt.Errorf("%s: no Path", item.infoPath())
+	}
+	if item.OriginalPath, err = unescapeTrashPath(path); err != nil {
+		return err
+	}
+	if !filepath.IsAbs(item.OriginalPath) {
+		item.OriginalPath = filepath.Join(topDir, item.OriginalPath)
+	}
+	item.DeletionDate, _ = time.ParseInLocation(trashDateLayout, date, time.Local)
+	return nil
+}
+
+// trashItemSize returns the apparent size of a trashed file or tree.
+func trashItemSize(path string) int64 {
+	usage, _ := DiskUsage(context.Background(), path)
+	return usage.Bytes
+}
+
+// escapeTrashPath percent-encodes a path for the Path key of a .trashinfo
+// file, keeping slashes and the characters URIs leave unreserved.
+func escapeTrashPath(path string) string {
+	var b strings.Builder
+	for _, c := range []byte(filepath.ToSlash(path)) {
+		if 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || strings.IndexByte("/-_.~", c) >= 0 {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// unescapeTrashPath decodes the Path key of a .trashinfo file.
+func unescapeTrashPath(value string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] != '%' {
+			b.WriteByte(value[i])
+			continue
+		}
+		if i+2 >= len(value) {
+			return "", fmt.Errorf("invalid escape in %q", value)
+		}
+		c, err := strconv.ParseUint(value[i+1:i+3], 16, 8)
+		if err != nil {
+			return "", fmt.Errorf("invalid escape in %q", value)
+		}
+		b.WriteByte(byte(c))
+		i += 2
+	}
+	return filepath.FromSlash(b.String()), nil
+}
+
+// RestoreFromTrash moves item back to its original path, recreating missing
+// parent directories, and returns the path it was restored to. An existing
+// path is never overwritten: restoring fails with an error satisfying
+// os.IsExist, unless opts.Policy is ConflictRename, which restores to the
+// next free name instead.
+func RestoreFromTrash(item TrashItem, opts ConflictOptions) (string, error) {
+	if err := os.MkdirAll(filepath.Dir(item.OriginalPath), 0755); err != nil {
+		return "", err
+	}
+	dst := item.OriginalPath
+	if opts.Policy == ConflictRename {
+		var err error
+		if dst, err = moveTo(context.Background(), item.path(), dst, CopyOptions{Conflict: opts}); err != nil {
+			return "", err
+		}
+	} else {
+		// Claiming dst first keeps a path created meanwhile from being replaced.
+		info, err := os.Lstat(item.path())
+		if err != nil {
+			return "", err
+		}
+		if err := reservePath(dst, info.IsDir()); err != nil {
+			if os.IsExist(err) {
+				return "", &os.PathError{Op: "restore", Path: dst, Err: os.ErrExist}
+			}
+			return "", err
+		}
+		if err := moveReserved(context.Background(), item.path(), dst, info.IsDir(), CopyOptions{}); err != nil {
+			return "", err
+		}
+	}
+	if err := os.Remove(item.infoPath()); err != nil && !os.IsNotExist(err) {
+		return dst, err
+	}
+	return dst, nil
+}
+
+// RemoveFromTrash deletes item from its trash can for good.
+func RemoveFromTrash(item TrashItem) error {
+	if err := os.RemoveAll(item.path()); err != nil {
+		return err
+	}
+	if err := os.Remove(item.infoPath()); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// EmptyTrashOptions selects the items EmptyTrash deletes. With no criteria
+// set, it deletes everything.
+type EmptyTrashOptions struct {
+	// OlderThan, if positive, selects items deleted longer ago than this.
+	OlderThan time.Duration
+	// LargerThan, if positive, selects items bigger than this. Set together
+	// with OlderThan, only items matching both are selected.
+	LargerThan Size
+	// MaxSize, if positive, also deletes the oldest of the remaining items
+	// until the trash cans together hold at most this much.
+	MaxSize Size
+	// TrashDir, if set, limits EmptyTrash to one trash can, such as the
+	// TrashDir of a TrashItem.
+	TrashDir string
+	// DryRun returns the items that would be deleted without deleting them.
+	DryRun bool
+}
+
+// EmptyTrash permanently deletes the items of the current user's trash cans
+// selected by opts, and returns them, oldest first. On error, it returns
+// the items deleted so far.
+func EmptyTrash(opts EmptyTrashOptions) ([]TrashItem, error) {
+	items, err := ListTrash()
+	if err != nil {
+		return nil, err
+	}
+	filtered := opts.OlderThan > 0 || opts.LargerThan > 0
+	everything := !filtered && opts.MaxSize <= 0
+	now := time.Now()
+
+	var selected, kept []TrashItem
+	var keptSize int64
+	for _, item := range items {
+		if opts.TrashDir != "" && item.TrashDir != filepath.Clean(opts.TrashDir) {
+			continue
+		}
+		match := everything || filtered &&
+			(opts.OlderThan <= 0 || now.Sub(item.DeletionDate) > opts.OlderThan) &&
+			(opts.LargerThan <= 0 || item.Size > int64(opts.LargerThan))
+		if match {
+			selected = append(selected, item)
+		} else {
+			kept = append(kept, item)
+			keptSize += item.Size
+		}
+	}
+	if opts.MaxSize > 0 {
+		for len(kept) > 0 && keptSize > int64(opts.MaxSize) {
+			selected = append(selected, kept[0])
+			keptSize -= kept[0].Size
+			kept = kept[1:]
+		}
+		sort.SliceStable(selected, func(i, j int) bool {
+			return selected[i].DeletionDate.Before(selected[j].DeletionDate)
+		})
+	}
+	if opts.DryRun {
+		return selected, nil
+	}
+	for i, item := range selected {
+		if err := RemoveFromTrash(item); err != nil {
+			return selected[:i], err
+		}
+	}
+	return selected, nil
+}
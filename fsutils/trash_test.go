@@ -0,0 +1,145 @@
+package fsutils_test
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/utsav-56/go_fs_utils/fsutils"
+)
+
+func TestTrash(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("the trash is only implemented on Linux")
+	}
+	// Create a temporary directory for our tests
+	tempDir, err := os.MkdirTemp("", "fsutils-trash-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+	t.Setenv("XDG_DATA_HOME", filepath.Join(tempDir, "data"))
+	home := filepath.Join(tempDir, "data", "Trash")
+
+	file := filepath.Join(tempDir, "my notes.txt")
+	os.WriteFile(file, []byte("notes"), 0644)
+	dir := filepath.Join(tempDir, "project")
+	fsutils.Mkdir(filepath.Join(dir, "src"))
+	os.WriteFile(filepath.Join(dir, "src", "main.go"), []byte("package main"), 0644)
+
+	// Test a file goes to the home trash with a .trashinfo file
+	item, err := fsutils.MoveToTrash(file)
+	if err != nil {
+		t.Fatalf("MoveToTrash failed: %v", err)
+	}
+	if item.TrashDir != home || item.Name != "my notes.txt" || item.OriginalPath != file || item.Size != 5 || fsutils.FileExists(file) {
+		t.Errorf("MoveToTrash returned %+v", item)
+	}
+	info, err := os.ReadFile(filepath.Join(home, "info", "my notes.txt.trashinfo"))
+	if err != nil {
+		t.Fatalf("Failed to read the .trashinfo file: %v", err)
+	}
+	want := "[Trash Info]\nPath=" + strings.ReplaceAll(file, " ", "%20") + "\nDeletionDate=" + item.DeletionDate.Format("2006-01-02T15:04:05") + "\n"
+	if string(info) != want {
+		t.Errorf(".trashinfo contains %q, want %q", info, want)
+	}
+
+	// Test a second item with the same name gets a new one
+	os.WriteFile(file, []byte("newer notes"), 0644)
+	second, err := fsutils.MoveToTrash(file)
+	if err != nil || second.Name != "my notes-1.txt" {
+		t.Errorf("MoveToTrash of a name already in the trash returned %+v, %v", second, err)
+	}
+	if _, err := fsutils.MoveToTrash(dir); err != nil {
+		t.Fatalf("MoveToTrash of a directory failed: %v", err)
+	}
+
+	// Test listing
+	list := func() []fsutils.TrashItem {
+		items, err := fsutils.ListTrash()
+		if err != nil {
+			t.Fatalf("ListTrash failed: %v", err)
+		}
+		var ours []fsutils.TrashItem
+		for _, item := range items {
+			if item.TrashDir == home {
+				ours = append(ours, item)
+			}
+		}
+		return ours
+	}
+	items := list()
+	if len(items) != 3 {
+		t.Fatalf("ListTrash returned %d items, want 3", len(items))
+	}
+	for _, item := range items {
+		if item.Name == "project" && (!item.IsDir || item.OriginalPath != dir || item.Size != 12) {
+			t.Errorf("ListTrash returned %+v for the directory", item)
+		}
+	}
+
+	// Test restoring, and that an existing path is not overwritten
+	if path, err := fsutils.RestoreFromTrash(second, fsutils.ConflictOptions{}); err != nil || path != file {
+		t.Fatalf("RestoreFromTrash returned %q, %v", path, err)
+	}
+	if _, err := fsutils.RestoreFromTrash(item, fsutils.ConflictOptions{}); !os.IsExist(err) {
+		t.Errorf("RestoreFromTrash over an existing file returned %v", err)
+	}
+	path, err := fsutils.RestoreFromTrash(item, fsutils.ConflictOptions{Policy: fsutils.ConflictRename})
+	if err != nil || path != filepath.Join(tempDir, "my notes (1).txt") {
+		t.Errorf("RestoreFromTrash with ConflictRename returned %q, %v", path, err)
+	}
+	if data, _ := os.ReadFile(file); string(data) != "newer notes" {
+		t.Errorf("restored file contains %q", data)
+	}
+
+	// Test a directory is restored, but not over an empty directory in its place
+	for _, item := range list() {
+		if item.Name != "project" {
+			continue
+		}
+		fsutils.Mkdir(dir)
+		if _, err := fsutils.RestoreFromTrash(item, fsutils.ConflictOptions{}); !os.IsExist(err) {
+			t.Errorf("RestoreFromTrash over an empty directory returned %v", err)
+		}
+		os.Remove(dir)
+		if _, err := fsutils.RestoreFromTrash(item, fsutils.ConflictOptions{}); err != nil || !fsutils.FileExists(filepath.Join(dir, "src", "main.go")) {
+			t.Errorf("RestoreFromTrash of a directory failed: %v", err)
+		}
+		if _, err := fsutils.MoveToTrash(dir); err != nil {
+			t.Fatalf("MoveToTrash of a directory failed: %v", err)
+		}
+	}
+
+	// Test emptying by age and total size
+	for _, name := range []string{"old.bin", "big.bin"} {
+		os.WriteFile(filepath.Join(tempDir, name), make([]byte, 100), 0644)
+		if _, err := fsutils.MoveToTrash(filepath.Join(tempDir, name)); err != nil {
+			t.Fatalf("MoveToTrash failed: %v", err)
+		}
+	}
+	backdate := func(name string, days int) {
+		info := "[Trash Info]\nPath=" + filepath.Join(tempDir, name) + "\nDeletionDate=" + time.Now().AddDate(0, 0, -days).Format("2006-01-02T15:04:05") + "\n"
+		os.WriteFile(filepath.Join(home, "info", name+".trashinfo"), []byte(info), 0600)
+	}
+	backdate("old.bin", 40)
+	backdate("project", 20)
+
+	removed, err := fsutils.EmptyTrash(fsutils.EmptyTrashOptions{OlderThan: 30 * 24 * time.Hour, TrashDir: home, DryRun: true})
+	if err != nil || len(removed) != 1 || removed[0].Name != "old.bin" || len(list()) != 3 {
+		t.Errorf("EmptyTrash dry run returned %v, %v", removed, err)
+	}
+	removed, err = fsutils.EmptyTrash(fsutils.EmptyTrashOptions{MaxSize: 100, TrashDir: home})
+	if err != nil || len(removed) != 2 || removed[0].Name != "old.bin" || removed[1].Name != "project" {
+		t.Errorf("EmptyTrash with MaxSize removed %v, %v", removed, err)
+	}
+	if items := list(); len(items) != 1 || items[0].Name != "big.bin" {
+		t.Errorf("EmptyTrash left %v", items)
+	}
+	if _, err := fsutils.EmptyTrash(fsutils.EmptyTrashOptions{TrashDir: home}); err != nil || len(list()) != 0 {
+		t.Errorf("EmptyTrash did not empty the trash: %v", err)
+	}
+}
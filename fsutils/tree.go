@@ -0,0 +1,195 @@
+// Package fsutils provides filesystem utility functions for Go applications.
+package fsutils
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TreeOptions controls which entries ReadTree collects and how Render draws them.
+type TreeOptions struct {
+	// MaxDepth stops the tree from descending below this depth.
+	// Zero means no limit.
+	MaxDepth int
+	// ShowHidden includes entries whose names start with a dot.
+	ShowHidden bool
+	// Filter, if set, decides which entries are included. Directories it
+	// rejects are left out together with their contents.
+	Filter func(path string, info fs.FileInfo) bool
+	// DirsFirst lists the directories of each level before its other entries.
+	DirsFirst bool
+	// ASCII draws the branches with plain ASCII instead of box-drawing characters.
+	ASCII bool
+	// ShowSize, ShowMode and ShowModTime add the size in binary units, the
+	// permissions and the modification time in brackets before each name.
+	ShowSize    bool
+	ShowMode    bool
+	ShowModTime bool
+}
+
+// TreeNode is one entry of a directory tree read by ReadTree.
+type TreeNode struct {
+	Name     string      // Base name, or the path given to ReadTree for the root
+	Path     string      // Path of the entry
+	Mode     fs.FileMode // Type and permission bits
+	Size     int64       // Size in bytes
+	ModTime  time.Time   // Modification time
+	Target   string      // Link target, for symlinks
+	Children []*TreeNode // Entries of a directory, in display order
+}
+
+// ReadTree reads the tree rooted at path into nodes. Entries are listed
+// with GetList and ordered by name, or with directories first when
+// opts.DirsFirst is set. Symlinks are not followed.
+func ReadTree(path string, opts TreeOptions) (*TreeNode, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	root := newTreeNode(path, info)
+	root.Name = path
+	return root, readTreeChildren(root, opts, 1)
+}
+
+// Tree renders the tree rooted at path as text, one entry per line, with
+// the root on the first line. It is meant for reports and for showing the
+// state of a directory in test failures.
+func Tree(path string, opts TreeOptions) (string, error) {
+	root, err := ReadTree(path, opts)
+	if err != nil {
+		return "", err
+	}
+	return root.Render(opts), nil
+}
+
+// newTreeNode describes the entry at path.
+func newTreeNode(path string, info fs.FileInfo) *TreeNode {
+	node := &TreeNode{Name: info.Name(), Path: path, Mode: info.Mode(), Size: info.Size(), ModTime: info.ModTime()}
+	if info.Mode()&fs.ModeSymlink != 0 {
+		node.Target, _ = os.Readlink(path)
+	}
+	return node
+}
+
+// readTreeChildren fills in the entries of the directory node, whose
+// children are at depth.
+func readTreeChildren(node *TreeNode, opts TreeOptions, depth int) error {
+	if !node.Mode.IsDir() || (opts.MaxDepth > 0 && depth > opts.MaxDepth) {
+		return nil
+	}
+	names, err := GetList(node.Path)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if !opts.ShowHidden && strings.HasPrefix(name, ".") {
+			continue
+		}
+		path := filepath.Join(node.Path, name)
+		info, err := os.Lstat(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if opts.Filter != nil && !opts.Filter(path, info) {
+			continue
+		}
+		child := newTreeNode(path, info)
+		if err := readTreeChildren(child, opts, depth+1); err != nil {
+			return err
+		}
+		node.Children = append(node.Children, child)
+	}
+	if opts.DirsFirst {
+		sort.SliceStable(node.Children, func(i, j int) bool {
+			return node.Children[i].Mode.IsDir() && !node.Children[j].Mode.IsDir()
+		})
+	}
+	return nil
+}
+
+// Render draws the tree below n as text, one entry per line.
+// Only the display fields of opts are used.
+func (n *TreeNode) Render(opts TreeOptions) string {
+	var b strings.Builder
+	b.WriteString(n.label(opts))
+	b.WriteByte('\n')
+	n.renderChildren(&b, "", opts)
+	return b.String()
+}
+
+// renderChildren draws the entries of n, each line starting with prefix.
+func (n *TreeNode) renderChildren(b *strings.Builder, prefix string, opts TreeOptions) {
+	branch, last, indent, lastIndent := "├── ", "└── ", "│   ", "    "
+	if opts.ASCII {
+		branch, last, indent = "|-- ", "`-- ", "|   "
+	}
+	for i, child := range n.Children {
+		connector, childPrefix := branch, prefix+indent
+		if i == len(n.Children)-1 {
+			connector, childPrefix = last, prefix+lastIndent
+		}
+		b.WriteString(prefix + connector + child.label(opts) + "\n")
+		child.renderChildren(b, childPrefix, opts)
+	}
+}
+
+// label returns the text drawn for n: its name preceded by the requested
+// details and followed by a symlink's target.
+func (n *TreeNode) label(opts TreeOptions) string {
+	var details []string
+	if opts.ShowMode {
+		details = append(details, n.Mode.String())
+	}
+	if opts.ShowSize {
+		details = append(details, fmt.Sprintf("%10s", Size(n.Size).String()))
+	}
+	if opts.ShowModTime {
+		details = append(details, n.ModTime.Format("Jan _2 15:04"))
+	}
+	label := n.Name
+	if len(details) > 0 {
+		label = "[" + strings.Join(details, " ") + "]  " + label
+	}
+	if n.Target != "" {
+		label += " -> " + n.Target
+	}
+	return label
+}
+
+// Count returns the number of directories and other entries below n.
+func (n *TreeNode) Count() (dirs, files int) {
+	for _, child := range n.Children {
+		if child.Mode.IsDir() {
+			dirs++
+		} else {
+			files++
+		}
+		d, f := child.Count()
+		dirs, files = dirs+d, files+f
+	}
+	return dirs, files
+}
+
+// MarshalJSON encodes the node with the keys and type names of FileRecord.
+func (n *TreeNode) MarshalJSON() ([]byte, error) {
+	type node struct {
+		Name     string      `json:"name"`
+		Path     string      `json:"path"`
+		Type     string      `json:"type"`
+		Size     int64       `json:"size"`
+		Mode     string      `json:"mode"`
+		ModTime  time.Time   `json:"dateModified"`
+		Target   string      `json:"target,omitempty"`
+		Children []*TreeNode `json:"children,omitempty"`
+	}
+	return json.Marshal(node{n.Name, n.Path, typeName(n.Mode), n.Size, n.Mode.String(), n.ModTime, n.Target, n.Children})
+}
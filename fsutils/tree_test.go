@@ -0,0 +1,87 @@
+package fsutils_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/utsav-56/go_fs_utils/fsutils"
+)
+
+func TestTree(t *testing.T) {
+	// Create a temporary directory for our tests
+	tempDir, err := os.MkdirTemp("", "fsutils-tree-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	fsutils.Mkdir(filepath.Join(tempDir, "b", "deep"))
+	fsutils.Touch(filepath.Join(tempDir, "a.txt"))
+	fsutils.Touch(filepath.Join(tempDir, ".hidden"))
+	fsutils.Touch(filepath.Join(tempDir, "b", "c.txt"))
+	fsutils.Touch(filepath.Join(tempDir, "b", "deep", "d.txt"))
+
+	// Test the default rendering
+	got, err := fsutils.Tree(tempDir, fsutils.TreeOptions{})
+	if err != nil {
+		t.Fatalf("Tree failed: %v", err)
+	}
+	want := tempDir + "\n" +
+		"├── a.txt\n" +
+		"└── b\n" +
+		"    ├── c.txt\n" +
+		"    └── deep\n" +
+		"        └── d.txt\n"
+	if got != want {
+		t.Errorf("Tree rendered:\n%s\nwant:\n%s", got, want)
+	}
+
+	// Test directories first, ASCII, depth limit and hidden files
+	opts := fsutils.TreeOptions{DirsFirst: true, ASCII: true, MaxDepth: 1, ShowHidden: true}
+	got, err = fsutils.Tree(tempDir, opts)
+	if err != nil {
+		t.Fatalf("Tree failed: %v", err)
+	}
+	want = tempDir + "\n" +
+		"|-- b\n" +
+		"|-- .hidden\n" +
+		"`-- a.txt\n"
+	if got != want {
+		t.Errorf("Tree with options rendered:\n%s\nwant:\n%s", got, want)
+	}
+
+	// Test sizes are shown in binary units
+	os.WriteFile(filepath.Join(tempDir, "a.txt"), make([]byte, 1536), 0644)
+	got, err = fsutils.Tree(tempDir, fsutils.TreeOptions{MaxDepth: 1, ShowSize: true})
+	if err != nil {
+		t.Fatalf("Tree failed: %v", err)
+	}
+	if want := "├── [   1.5 KiB]  a.txt\n"; !strings.Contains(got, want) {
+		t.Errorf("Tree with sizes rendered:\n%s\nwant a line %q", got, want)
+	}
+
+	// Test counts and JSON
+	root, err := fsutils.ReadTree(tempDir, fsutils.TreeOptions{})
+	if err != nil {
+		t.Fatalf("ReadTree failed: %v", err)
+	}
+	if dirs, files := root.Count(); dirs != 2 || files != 3 {
+		t.Errorf("Count = %d dirs, %d files, want 2 dirs, 3 files", dirs, files)
+	}
+	data, err := json.Marshal(root)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	var decoded struct {
+		Type     string `json:"type"`
+		Children []struct {
+			Name string `json:"name"`
+		} `json:"children"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil || decoded.Type != "dir" || len(decoded.Children) != 2 {
+		t.Errorf("Unexpected JSON %s: %v", data, err)
+	}
+}
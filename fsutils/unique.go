@@ -0,0 +1,202 @@
+// Package fsutils provides filesystem utility functions for Go applications.
+package fsutils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ConflictPolicy decides what a copy, move or touch does when its
+// destination already exists.
+type ConflictPolicy int
+
+const (
+	// ConflictOverwrite replaces the existing file, after backing it up if
+	// a BackupOptions says so.
+	ConflictOverwrite ConflictPolicy = iota
+	// ConflictRename leaves the existing file alone and writes to the next
+	// free name made from the conflict template instead.
+	ConflictRename
+)
+
+// Templates for the names tried by CreateUnique and ConflictRename. In a
+// template, "{name}" is the base name without its extension, "{ext}" the
+// extension with its dot, "{n}" the attempt number starting at 1, and
+// "{time}" the UTC time of the call, one millisecond later for each attempt.
+const (
+	// TemplateParens gives "report (1).txt", as desktop file managers do.
+	TemplateParens = "{name} ({n}){ext}"
+	// TemplateDashed gives "report-1.txt".
+	TemplateDashed = "{name}-{n}{ext}"
+	// TemplateTimestamp gives "report-20060102T150405.000Z.txt".
+	TemplateTimestamp = "{name}-{time}{ext}"
+)
+
+// maxUniqueAttempts bounds the names CreateUnique tries before giving up.
+const maxUniqueAttempts = 10000
+
+// ConflictOptions selects the conflict policy of a copy, move or touch.
+type ConflictOptions struct {
+	// Policy is what happens when the destination exists.
+	Policy ConflictPolicy
+	// Template makes the names ConflictRename tries. Defaults to
+	// TemplateParens.
+	Template string
+}
+
+// UniquePath returns path if nothing exists there, and otherwise the first
+// free name made from it with TemplateParens, such as "photo (2).jpg". The
+// name is reserved by creating an empty file with O_EXCL, so concurrent
+// callers never get the same name; the caller may then overwrite or remove it.
+func UniquePath(path string) (string, error) {
+	file, err := CreateUnique(path, TemplateParens)
+	if err != nil {
+		return "", err
+	}
+	return file.Name(), file.Close()
+}
+
+// CreateUnique creates and opens a new file at path, or if path exists, at
+// the first free name made from it with template. Every name is created with
+// O_EXCL, so the file returned is always new, even with concurrent callers.
+// The file's Name is the path that was used.
+func CreateUnique(path, template string) (*os.File, error) {
+	var file *os.File
+	err := reserveUnique(path, template, func(name string) (err error) {
+		file, err = os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0666)
+		return err
+	})
+	return file, err
+}
+
+// reserveUnique calls create with path, and then with the names made from
+// template, until one does not fail because the name exists. create must
+// fail with an error satisfying os.IsExist when the name is taken.
+func reserveUnique(path, template string, create func(name string) error) error {
+	if template == "" {
+		template = TemplateParens
+	}
+	if !strings.Contains(template, "{n}") && !strings.Contains(template, "{time}") {
+		return fmt.Errorf("conflict template %q has neither {n} nor {time}", template)
+	}
+	err := create(path)
+	if !os.IsExist(err) {
+		return err
+	}
+
+	dir, base := filepath.Split(path)
+	ext := filepath.Ext(base)
+	name := strings.TrimSuffix(base, ext)
+	if name == "" {
+		name, ext = base, "" // a dotfile such as ".profile" has no extension
+	}
+	now := time.Now().UTC()
+	for n := 1; n <= maxUniqueAttempts; n++ {
+		candidate := strings.NewReplacer(
+			"{name}", name,
+			"{ext}", ext,
+			"{n}", strconv.Itoa(n),
+			"{time}", now.Add(time.Duration(n-1)*time.Millisecond).Format(backupTimeLayout),
+		).Replace(template)
+		err := create(filepath.Join(dir, candidate))
+		if !os.IsExist(err) {
+			return err
+		}
+	}
+	return &os.PathError{Op: "create", Path: path, Err: fmt.Errorf("no free name after %d attempts", maxUniqueAttempts)}
+}
+
+// TouchWithOptions is like Touch, but with ConflictRename an existing file
+// is left untouched and a new empty file is created at the next free name.
+// It returns the path that was touched.
+func TouchWithOptions(path string, opts ConflictOptions) (string, error) {
+	if opts.Policy != ConflictRename {
+		return path, Touch(path)
+	}
+	file, err := CreateUnique(path, opts.Template)
+	if err != nil {
+		return "", err
+	}
+	return file.Name(), file.Close()
+}
+
+// MoveFileWithOptions is like MoveFile, but follows opts.Conflict and
+// opts.Backup when dst exists and falls back to copying across filesystems
+// as MoveContext does. It returns the path the file was moved to.
+func MoveFileWithOptions(src, dst string, opts CopyOptions) (string, error) {
+	info, err := os.Stat(src)
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("'%s' is a directory, use MoveDir or Mv", src)
+	}
+	return moveTo(context.Background(), src, dst, opts)
+}
+
+// moveTo moves src to dst following opts.Conflict and returns the path used.
+// With ConflictRename the new name is reserved first, with an empty file for
+// a file and an empty directory for a directory, and src is renamed over it.
+func moveTo(ctx context.Context, src, dst string, opts CopyOptions) (string, error) {
+	if opts.Conflict.Policy != ConflictRename {
+		return dst, MoveContext(ctx, src, dst, opts)
+	}
+	info, err := os.Lstat(src)
+	if err != nil {
+		return "", err
+	}
+	target := dst
+	err = reserveUnique(dst, opts.Conflict.Template, func(name string) error {
+		target = name
+		return reservePath(name, info.IsDir())
+	})
+	if err != nil {
+		return "", err
+	}
+	if err := moveReserved(ctx, src, target, info.IsDir(), opts); err != nil {
+		return "", err
+	}
+	return target, nil
+}
+
+// reservePath claims the free name path for a move, with an empty directory
+// if dir is set and an empty file otherwise. It fails with an error
+// satisfying os.IsExist if path is taken.
+func reservePath(path string, dir bool) error {
+	if dir {
+		return os.Mkdir(path, 0755)
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0666)
+	if err == nil {
+		err = file.Close()
+	}
+	return err
+}
+
+// moveReserved moves src over target, which reservePath has claimed for it,
+// and gives target up again if the move fails.
+func moveReserved(ctx context.Context, src, target string, dir bool, opts CopyOptions) error {
+	// The target is ours now, so it is replaced rather than renamed again
+	// or backed up.
+	opts.Conflict, opts.Backup = ConflictOptions{}, BackupOptions{}
+	var err error
+	if dir {
+		err = replaceEmptyDir(src, target)
+		if errors.Is(err, syscall.EXDEV) {
+			err = moveAcross(ctx, src, target, opts)
+		}
+	} else {
+		err = MoveContext(ctx, src, target, opts)
+	}
+	if err != nil {
+		os.Remove(target)
+	}
+	return err
+}
@@ -0,0 +1,157 @@
+package fsutils_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/utsav-56/go_fs_utils/fsutils"
+)
+
+func TestUniquePath(t *testing.T) {
+	// Create a temporary directory for our tests
+	tempDir, err := os.MkdirTemp("", "fsutils-unique-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	file := filepath.Join(tempDir, "photo.jpg")
+
+	// Test a free path is returned as is and reserved
+	for _, want := range []string{"photo.jpg", "photo (1).jpg", "photo (2).jpg"} {
+		got, err := fsutils.UniquePath(file)
+		if err != nil || got != filepath.Join(tempDir, want) {
+			t.Errorf("UniquePath returned %q, %v; want %q", got, err, want)
+		}
+		if !fsutils.FileExists(got) {
+			t.Errorf("UniquePath did not reserve %q", got)
+		}
+	}
+
+	// Test templates and dotfiles
+	tests := []struct {
+		name, template, want string
+	}{
+		{"photo.jpg", fsutils.TemplateDashed, "photo-1.jpg"},
+		{".profile", fsutils.TemplateParens, ".profile (1)"},
+		{"archive.tar.gz", "{name}.{n}{ext}", "archive.tar.1.gz"},
+	}
+	for _, tt := range tests {
+		os.WriteFile(filepath.Join(tempDir, tt.name), nil, 0644)
+		f, err := fsutils.CreateUnique(filepath.Join(tempDir, tt.name), tt.template)
+		if err != nil {
+			t.Errorf("CreateUnique(%q, %q) failed: %v", tt.name, tt.template, err)
+			continue
+		}
+		f.Close()
+		if got := filepath.Base(f.Name()); got != tt.want {
+			t.Errorf("CreateUnique(%q, %q) created %q, want %q", tt.name, tt.template, got, tt.want)
+		}
+	}
+	f, err := fsutils.CreateUnique(file, fsutils.TemplateTimestamp)
+	if err != nil {
+		t.Fatalf("CreateUnique with a timestamp failed: %v", err)
+	}
+	f.Close()
+	if name := filepath.Base(f.Name()); !strings.HasPrefix(name, "photo-") || !strings.HasSuffix(name, "Z.jpg") {
+		t.Errorf("CreateUnique with a timestamp created %q", name)
+	}
+	if _, err := fsutils.CreateUnique(file, "{name} copy{ext}"); err == nil {
+		t.Errorf("CreateUnique with a template without {n} succeeded")
+	}
+
+	// Test concurrent callers never share a name
+	const callers = 20
+	names := make([]string, callers)
+	var wg sync.WaitGroup
+	for i := range names {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			names[i], _ = fsutils.UniquePath(filepath.Join(tempDir, "upload.bin"))
+		}(i)
+	}
+	wg.Wait()
+	seen := make(map[string]bool)
+	for _, name := range names {
+		if name == "" || seen[name] {
+			t.Errorf("concurrent UniquePath returned %q twice or failed", name)
+		}
+		seen[name] = true
+	}
+}
+
+func TestConflictRename(t *testing.T) {
+	// Create a temporary directory for our tests
+	tempDir, err := os.MkdirTemp("", "fsutils-conflict-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	src := filepath.Join(tempDir, "src.txt")
+	dst := filepath.Join(tempDir, "dst.txt")
+	os.WriteFile(src, []byte("new"), 0644)
+	os.WriteFile(dst, []byte("old"), 0644)
+	opts := fsutils.CopyOptions{Conflict: fsutils.ConflictOptions{Policy: fsutils.ConflictRename}}
+	read := func(path string) string {
+		data, _ := os.ReadFile(path)
+		return string(data)
+	}
+
+	// Test a copy goes to the next free name
+	result, err := fsutils.CopyFileWithOptions(src, dst, opts)
+	if err != nil {
+		t.Fatalf("CopyFileWithOptions failed: %v", err)
+	}
+	if result.Path != filepath.Join(tempDir, "dst (1).txt") || read(result.Path) != "new" || read(dst) != "old" {
+		t.Errorf("CopyFileWithOptions wrote %q", result.Path)
+	}
+
+	// Test a failed copy gives up the name it reserved
+	ctx, cancel := context.WithCancel(context.Background())
+	failing := opts
+	failing.Progress = func(fsutils.Progress) { cancel() }
+	if _, err := fsutils.CopyFileContext(ctx, src, dst, failing); err != context.Canceled {
+		t.Errorf("Cancelled CopyFileContext returned %v", err)
+	}
+	if fsutils.FileExists(filepath.Join(tempDir, "dst (2).txt")) {
+		t.Errorf("Failed CopyFileContext left its reserved name behind")
+	}
+
+	// Test a move goes to the next free name
+	opts.Conflict.Template = fsutils.TemplateDashed
+	moved, err := fsutils.MoveFileWithOptions(src, dst, opts)
+	if err != nil {
+		t.Fatalf("MoveFileWithOptions failed: %v", err)
+	}
+	if moved != filepath.Join(tempDir, "dst-1.txt") || read(moved) != "new" || read(dst) != "old" || fsutils.FileExists(src) {
+		t.Errorf("MoveFileWithOptions moved to %q", moved)
+	}
+
+	// Test a directory moved onto an existing path goes to the next free name
+	dir := filepath.Join(tempDir, "dir")
+	fsutils.Mkdir(filepath.Join(dir, "sub"))
+	os.WriteFile(filepath.Join(dir, "sub", "f.txt"), []byte("f"), 0644)
+	existing := filepath.Join(tempDir, "existing")
+	fsutils.Mkdir(existing)
+	if err := fsutils.MoveContext(context.Background(), dir, existing, opts); err != nil {
+		t.Fatalf("MoveContext of a directory failed: %v", err)
+	}
+	if read(filepath.Join(tempDir, "existing-1", "sub", "f.txt")) != "f" || fsutils.DirExists(dir) {
+		t.Errorf("MoveContext did not move the directory to the next free name")
+	}
+
+	// Test touch creates a new file instead of updating the existing one
+	touched, err := fsutils.TouchWithOptions(dst, fsutils.ConflictOptions{Policy: fsutils.ConflictRename})
+	if err != nil || touched != filepath.Join(tempDir, "dst (2).txt") || read(dst) != "old" {
+		t.Errorf("TouchWithOptions returned %q, %v", touched, err)
+	}
+	if touched, err := fsutils.TouchWithOptions(dst, fsutils.ConflictOptions{}); err != nil || touched != dst {
+		t.Errorf("TouchWithOptions without a policy returned %q, %v", touched, err)
+	}
+}
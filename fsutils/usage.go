@@ -0,0 +1,45 @@
+// Package fsutils provides filesystem utility functions for Go applications.
+package fsutils
+
+import "context"
+
+// Usage summarises the space taken by a tree, as reported by DiskUsage.
+type Usage struct {
+	Files     int   // Entries that are not directories
+	Dirs      int   // Directories, including the root
+	Bytes     int64 // Apparent size of the regular files
+	Allocated int64 // Bytes allocated on disk for the regular files
+}
+
+// DiskUsage measures path and everything below it like du: symlinks are not
+// followed and files with several hardlinks in the tree are counted once.
+// It stops with ctx.Err() once ctx is cancelled.
+func DiskUsage(ctx context.Context, path string) (Usage, error) {
+	var usage Usage
+	type inode struct{ dev, ino uint64 }
+	seen := make(map[inode]bool)
+	err := Walk(ctx, path, WalkOptions{}, func(entry WalkEntry) error {
+		if entry.Info.IsDir() {
+			usage.Dirs++
+			return nil
+		}
+		usage.Files++
+		if !entry.Info.Mode().IsRegular() {
+			return nil
+		}
+		if dev, ino, ok := fileDevIno(entry.Info); ok {
+			if seen[inode{dev, ino}] {
+				return nil
+			}
+			seen[inode{dev, ino}] = true
+		}
+		allocated, ok := fileAllocated(entry.Info)
+		if !ok {
+			allocated = entry.Info.Size()
+		}
+		usage.Bytes += entry.Info.Size()
+		usage.Allocated += allocated
+		return nil
+	})
+	return usage, err
+}
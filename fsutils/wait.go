@@ -0,0 +1,155 @@
+// Package fsutils provides filesystem utility functions for Go applications.
+package fsutils
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// PathState is the condition WaitForPath waits for.
+type PathState int
+
+const (
+	// PathExists waits until something exists at the path.
+	PathExists PathState = iota
+	// PathGone waits until nothing exists at the path.
+	PathGone
+)
+
+// waitPollInterval is how often waits re-check the path when no watcher is
+// available, and the safety-net interval when one is.
+const waitPollInterval = 250 * time.Millisecond
+
+// StableOptions controls the behaviour of WaitUntilStable.
+type StableOptions struct {
+	// Quiet is how long size and modification time must stay unchanged.
+	// Defaults to one second.
+	Quiet time.Duration
+	// NoOpenWriters also waits until no process has the file open for
+	// writing. It is only supported on Linux, where it inspects /proc.
+	NoOpenWriters bool
+}
+
+// WaitForPath blocks until path reaches state or ctx is done, in which case
+// it returns ctx.Err(). It watches the parent directory when possible and
+// polls otherwise.
+func WaitForPath(ctx context.Context, path string, state PathState) error {
+	reached := func() bool {
+		_, err := os.Lstat(path)
+		if state == PathGone {
+			return os.IsNotExist(err)
+		}
+		return err == nil
+	}
+	events := watchParent(path)
+	defer events.Close()
+	for !reached() {
+		if err := events.wait(ctx, waitPollInterval); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WaitUntilStable blocks until path exists and its size and modification time
+// have not changed for opts.Quiet, which is how long an upstream writer is
+// assumed to pause at most. With opts.NoOpenWriters it also waits until no
+// process holds the file open for writing. It returns ctx.Err() if ctx is
+// done first.
+func WaitUntilStable(ctx context.Context, path string, opts StableOptions) error {
+	quiet := opts.Quiet
+	if quiet <= 0 {
+		quiet = time.Second
+	}
+	if err := WaitForPath(ctx, path, PathExists); err != nil {
+		return err
+	}
+	events := watchParent(path)
+	defer events.Close()
+
+	var size int64
+	var modTime, since time.Time
+	for {
+		info, err := os.Stat(path)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		now := time.Now()
+		if err != nil || since.IsZero() || info.Size() != size || !info.ModTime().Equal(modTime) {
+			if err == nil {
+				size, modTime = info.Size(), info.ModTime()
+			}
+			since = now
+		}
+
+		remaining := quiet - now.Sub(since)
+		if err == nil && remaining <= 0 {
+			if !opts.NoOpenWriters {
+				return nil
+			}
+			busy, err := hasOpenWriters(path)
+			if err != nil {
+				return err
+			}
+			if !busy {
+				return nil
+			}
+			remaining = waitPollInterval
+		}
+		if err := events.wait(ctx, remaining); err != nil {
+			return err
+		}
+	}
+}
+
+// parentEvents wakes a wait loop when something changes next to a path.
+// It degrades to plain timeouts when no watcher could be started.
+type parentEvents struct {
+	w      *Watcher
+	events <-chan Event
+}
+
+// watchParent watches the directory containing path for changes to path.
+func watchParent(path string) *parentEvents {
+	path = filepath.Clean(path)
+	opts := WatchOptions{
+		NonRecursive: true,
+		Filter:       func(p string) bool { return p == path },
+	}
+	w, err := newInotifyWatcher(filepath.Dir(path), opts)
+	if err != nil {
+		return &parentEvents{}
+	}
+	return &parentEvents{w: w, events: w.Events()}
+}
+
+// wait returns after an event, after timeout, or with ctx.Err() once ctx is
+// done. Timeouts are capped at waitPollInterval so missed events only delay
+// the caller.
+func (pe *parentEvents) wait(ctx context.Context, timeout time.Duration) error {
+	if timeout <= 0 || timeout > waitPollInterval {
+		timeout = waitPollInterval
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case _, ok := <-pe.events:
+		if !ok {
+			pe.events = nil // the watcher failed; keep polling
+		}
+	case <-timer.C:
+	}
+	return nil
+}
+
+// Close stops the underlying watcher, if any.
+func (pe *parentEvents) Close() error {
+	if pe.w == nil {
+		return nil
+	}
+	return pe.w.Close()
+}
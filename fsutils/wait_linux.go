@@ -0,0 +1,69 @@
+//go:build linux
+
+// Package fsutils provides filesystem utility functions for Go applications.
+package fsutils
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// hasOpenWriters reports whether any process has path open for writing.
+// It scans /proc/*/fd, so descriptors of processes the caller may not
+// inspect are not seen.
+func hasOpenWriters(path string) (bool, error) {
+	target, err := os.Stat(path)
+	if err != nil {
+		return false, err
+	}
+	procs, err := os.ReadDir("/proc")
+	if err != nil {
+		return false, err
+	}
+	for _, p := range procs {
+		if _, err := strconv.Atoi(p.Name()); err != nil {
+			continue
+		}
+		procDir := filepath.Join("/proc", p.Name())
+		fds, err := os.ReadDir(filepath.Join(procDir, "fd"))
+		if err != nil {
+			continue // exited or not ours to inspect
+		}
+		for _, fd := range fds {
+			info, err := os.Stat(filepath.Join(procDir, "fd", fd.Name()))
+			if err != nil || !os.SameFile(info, target) {
+				continue
+			}
+			if fdWritable(filepath.Join(procDir, "fdinfo", fd.Name())) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// fdWritable reads the open flags from a /proc/<pid>/fdinfo/<fd> file.
+func fdWritable(fdinfo string) bool {
+	f, err := os.Open(fdinfo)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		value, ok := strings.CutPrefix(scanner.Text(), "flags:")
+		if !ok {
+			continue
+		}
+		flags, err := strconv.ParseUint(strings.TrimSpace(value), 8, 64)
+		if err != nil {
+			return false
+		}
+		return flags&syscall.O_ACCMODE != syscall.O_RDONLY
+	}
+	return false
+}
@@ -0,0 +1,11 @@
+//go:build !linux
+
+// Package fsutils provides filesystem utility functions for Go applications.
+package fsutils
+
+import "os"
+
+// hasOpenWriters is not available on this platform.
+func hasOpenWriters(path string) (bool, error) {
+	return false, &os.PathError{Op: "open writers", Path: path, Err: ErrUnsupported}
+}
@@ -0,0 +1,106 @@
+package fsutils_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/utsav-56/go_fs_utils/fsutils"
+)
+
+func TestWaitForPath(t *testing.T) {
+	// Create a temporary directory for our tests
+	tempDir, err := os.MkdirTemp("", "fsutils-wait-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Test waiting for a file that appears later
+	file := filepath.Join(tempDir, "ready.flag")
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		fsutils.Touch(file)
+	}()
+	if err := fsutils.WaitForPath(ctx, file, fsutils.PathExists); err != nil {
+		t.Fatalf("WaitForPath(PathExists) failed: %v", err)
+	}
+
+	// Test waiting for it to be removed
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		os.Remove(file)
+	}()
+	if err := fsutils.WaitForPath(ctx, file, fsutils.PathGone); err != nil {
+		t.Fatalf("WaitForPath(PathGone) failed: %v", err)
+	}
+
+	// Test timeout
+	short, cancelShort := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancelShort()
+	err = fsutils.WaitForPath(short, filepath.Join(tempDir, "never"), fsutils.PathExists)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("WaitForPath should time out, got: %v", err)
+	}
+}
+
+func TestWaitUntilStable(t *testing.T) {
+	// Create a temporary directory for our tests
+	tempDir, err := os.MkdirTemp("", "fsutils-wait-stable-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Test a file still being written only settles after the last write
+	file := filepath.Join(tempDir, "upload.bin")
+	f, err := os.Create(file)
+	if err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	var lastWrite time.Time
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 5; i++ {
+			f.Write([]byte("chunk"))
+			lastWrite = time.Now()
+			time.Sleep(30 * time.Millisecond)
+		}
+	}()
+	quiet := 150 * time.Millisecond
+	opts := fsutils.StableOptions{Quiet: quiet}
+	if err := fsutils.WaitUntilStable(ctx, file, opts); err != nil {
+		t.Fatalf("WaitUntilStable failed: %v", err)
+	}
+	<-done
+	if time.Since(lastWrite) < quiet {
+		t.Errorf("WaitUntilStable returned %v after the last write, want at least %v", time.Since(lastWrite), quiet)
+	}
+
+	// Test open writers keep the file busy
+	if runtime.GOOS != "linux" {
+		f.Close()
+		return
+	}
+	short, cancelShort := context.WithTimeout(context.Background(), 400*time.Millisecond)
+	defer cancelShort()
+	opts.NoOpenWriters = true
+	if err := fsutils.WaitUntilStable(short, file, opts); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("WaitUntilStable should wait for open writers, got: %v", err)
+	}
+	f.Close()
+	if err := fsutils.WaitUntilStable(ctx, file, opts); err != nil {
+		t.Errorf("WaitUntilStable failed after writer closed: %v", err)
+	}
+}
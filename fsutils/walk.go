@@ -0,0 +1,175 @@
+// Package fsutils provides filesystem utility functions for Go applications.
+package fsutils
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// WalkEntry describes one path visited by Walk.
+type WalkEntry struct {
+	Path  string      // Root joined with the entry's relative path
+	Rel   string      // Slash-separated path relative to the root, "." for the root itself
+	Depth int         // 0 for the root, 1 for its entries and so on
+	Info  fs.FileInfo // With FollowSymlinks, describes the link target
+}
+
+// WalkOptions controls the behaviour of Walk.
+type WalkOptions struct {
+	// MaxDepth stops the walk from descending below this depth.
+	// Zero means no limit.
+	MaxDepth int
+	// FollowSymlinks descends into symlinked directories and reports link
+	// targets instead of the links. Symlink loops are detected and not
+	// followed. The root is always followed.
+	FollowSymlinks bool
+	// Filter, if set, decides which entries are passed to the walk function.
+	// Directories it rejects are still descended into.
+	Filter func(entry WalkEntry) bool
+	// MinSize and MaxSize, if non-zero, leave out entries other than
+	// directories whose size is outside the range.
+	MinSize, MaxSize Size
+	// Ignore, if set, leaves out the paths it matches, relative to the root.
+	// Ignored directories are not descended into.
+	Ignore *IgnoreMatcher
+	// IgnoreFile names the ignore files, such as DefaultIgnoreFile, whose
+	// patterns apply to the directory they are in and everything below it,
+	// in addition to those of Ignore.
+	IgnoreFile string
+	// ContinueOnError makes Walk skip entries that cannot be read and carry
+	// on. The failures are returned together as a *MultiError.
+	ContinueOnError bool
+}
+
+// WalkFunc is called by Walk for every entry. Returning filepath.SkipDir
+// from a directory skips its contents, and from a file skips the rest of
+// its directory; filepath.SkipAll ends the walk without an error.
+type WalkFunc func(entry WalkEntry) error
+
+// Walk visits root and everything below it in lexical order, depth-first,
+// calling fn for each entry. It stops with ctx.Err() once ctx is cancelled.
+func Walk(ctx context.Context, root string, opts WalkOptions, fn WalkFunc) error {
+	w := &walker{ctx: ctx, opts: opts, fn: fn, errs: newErrorList("walk", opts.ContinueOnError)}
+	if err := w.run(root); err != nil {
+		return err
+	}
+	return w.errs.err(w.visited)
+}
+
+// walker holds the state of one Walk.
+type walker struct {
+	ctx       context.Context
+	opts      WalkOptions
+	fn        WalkFunc
+	errs      *errorList
+	ignore    *ignoreWalk
+	ancestors []fs.FileInfo // directories being walked, for loop detection
+	visited   int
+}
+
+// run walks the tree at root. Failures skipped under ContinueOnError are
+// left in w.errs.
+func (w *walker) run(root string) error {
+	info, err := os.Stat(root)
+	if err != nil {
+		return err
+	}
+	w.ignore = newIgnoreWalk(w.opts.Ignore, w.opts.IgnoreFile)
+	err = w.walk(root, ".", 0, info)
+	if err == filepath.SkipDir || err == filepath.SkipAll {
+		err = nil
+	}
+	return err
+}
+
+// walk visits path and, if it is a directory, its entries.
+func (w *walker) walk(path, rel string, depth int, info fs.FileInfo) error {
+	if err := w.ctx.Err(); err != nil {
+		return err
+	}
+	if w.ignore.ignored(rel, info.IsDir()) {
+		return nil
+	}
+	entry := WalkEntry{Path: path, Rel: rel, Depth: depth, Info: info}
+	w.visited++
+	if w.wants(entry) {
+		if err := w.fn(entry); err != nil {
+			if err == filepath.SkipDir && info.IsDir() {
+				return nil
+			}
+			return err
+		}
+	}
+	if !info.IsDir() || (w.opts.MaxDepth > 0 && depth >= w.opts.MaxDepth) {
+		return nil
+	}
+	for _, dir := range w.ancestors {
+		if os.SameFile(dir, info) {
+			return nil // symlink loop
+		}
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		if err = w.errs.record(path, err); err == errSkipped || os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	mark, err := w.ignore.enter(path, rel)
+	if err != nil {
+		if err = w.errs.record(path, err); err == errSkipped {
+			return nil
+		}
+		return err
+	}
+	defer w.ignore.leave(mark)
+	w.ancestors = append(w.ancestors, info)
+	defer func() { w.ancestors = w.ancestors[:len(w.ancestors)-1] }()
+
+	for _, d := range entries {
+		child := filepath.Join(path, d.Name())
+		childRel := d.Name()
+		if rel != "." {
+			childRel = rel + "/" + d.Name()
+		}
+		childInfo, err := w.stat(child, d)
+		if err != nil {
+			if err = w.errs.record(child, err); err == errSkipped || os.IsNotExist(err) {
+				continue
+			}
+			return err
+		}
+		if err := w.walk(child, childRel, depth+1, childInfo); err != nil {
+			if err == filepath.SkipDir {
+				return nil
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// wants reports whether entry is passed to the walk function.
+func (w *walker) wants(entry WalkEntry) bool {
+	if !entry.Info.IsDir() {
+		size := Size(entry.Info.Size())
+		if size < w.opts.MinSize || (w.opts.MaxSize > 0 && size > w.opts.MaxSize) {
+			return false
+		}
+	}
+	return w.opts.Filter == nil || w.opts.Filter(entry)
+}
+
+// stat describes the entry d found at path, following it if it is a symlink
+// and the walk follows symlinks. Dangling links are reported as links.
+func (w *walker) stat(path string, d fs.DirEntry) (fs.FileInfo, error) {
+	if w.opts.FollowSymlinks && d.Type()&fs.ModeSymlink != 0 {
+		if info, err := os.Stat(path); err == nil {
+			return info, nil
+		}
+	}
+	return d.Info()
+}
@@ -0,0 +1,77 @@
+package fsutils_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/utsav-56/go_fs_utils/fsutils"
+)
+
+func TestWalk(t *testing.T) {
+	// Create a temporary directory for our tests
+	tempDir, err := os.MkdirTemp("", "fsutils-walk-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	fsutils.Mkdir(filepath.Join(tempDir, "b", "deep"))
+	os.WriteFile(filepath.Join(tempDir, "a.txt"), []byte("aaaa"), 0644)
+	os.WriteFile(filepath.Join(tempDir, "b", "c.txt"), []byte("cc"), 0644)
+	os.WriteFile(filepath.Join(tempDir, "b", "deep", "d.txt"), []byte("d"), 0644)
+	if err := os.Symlink(tempDir, filepath.Join(tempDir, "b", "loop")); err != nil {
+		t.Fatalf("Failed to create symlink: %v", err)
+	}
+
+	walk := func(opts fsutils.WalkOptions) []string {
+		t.Helper()
+		var rels []string
+		err := fsutils.Walk(context.Background(), tempDir, opts, func(entry fsutils.WalkEntry) error {
+			rels = append(rels, entry.Rel)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Walk failed: %v", err)
+		}
+		return rels
+	}
+
+	// Test lexical order and relative paths
+	got := walk(fsutils.WalkOptions{})
+	want := []string{".", "a.txt", "b", "b/c.txt", "b/deep", "b/deep/d.txt", "b/loop"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Walk visited %v, want %v", got, want)
+	}
+
+	// Test depth limit and filter
+	got = walk(fsutils.WalkOptions{
+		MaxDepth: 2,
+		Filter:   func(entry fsutils.WalkEntry) bool { return entry.Info.Mode().IsRegular() },
+	})
+	want = []string{"a.txt", "b/c.txt"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Walk with MaxDepth and Filter visited %v, want %v", got, want)
+	}
+
+	// Test following symlinks stops at the loop
+	got = walk(fsutils.WalkOptions{FollowSymlinks: true})
+	want = []string{".", "a.txt", "b", "b/c.txt", "b/deep", "b/deep/d.txt", "b/loop"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Walk following symlinks visited %v, want %v", got, want)
+	}
+
+	// Test DiskUsage counts hardlinked files once
+	if err := os.Link(filepath.Join(tempDir, "a.txt"), filepath.Join(tempDir, "b", "a-link.txt")); err != nil {
+		t.Fatalf("Failed to create hardlink: %v", err)
+	}
+	usage, err := fsutils.DiskUsage(context.Background(), tempDir)
+	if err != nil {
+		t.Fatalf("DiskUsage failed: %v", err)
+	}
+	if usage.Files != 5 || usage.Dirs != 3 || usage.Bytes != 7 {
+		t.Errorf("DiskUsage = %+v, want 5 files, 3 dirs, 7 bytes", usage)
+	}
+}
@@ -0,0 +1,247 @@
+// Package fsutils provides filesystem utility functions for Go applications.
+package fsutils
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Op describes what happened to a watched path. Coalesced events may carry
+// several operations at once.
+type Op uint32
+
+const (
+	// OpCreate reports a new file or directory.
+	OpCreate Op = 1 << iota
+	// OpWrite reports modified file content.
+	OpWrite
+	// OpRemove reports a deleted path, or one moved out of the watched tree.
+	OpRemove
+	// OpRename reports a path moved within the watched tree; OldPath holds its previous name.
+	OpRename
+	// OpChmod reports changed permissions, ownership or timestamps.
+	OpChmod
+	// OpRescan reports that events were lost, for example because the kernel
+	// queue overflowed. Consumers should rescan the tree below Path.
+	OpRescan
+)
+
+// String returns the operation names joined with "|".
+func (op Op) String() string {
+	var names []string
+	for _, o := range []struct {
+		op   Op
+		name string
+	}{
+		{OpCreate, "CREATE"}, {OpWrite, "WRITE"}, {OpRemove, "REMOVE"},
+		{OpRename, "RENAME"}, {OpChmod, "CHMOD"}, {OpRescan, "RESCAN"},
+	} {
+		if op&o.op != 0 {
+			names = append(names, o.name)
+		}
+	}
+	if len(names) == 0 {
+		return "NONE"
+	}
+	return strings.Join(names, "|")
+}
+
+// Event is a change observed by a Watcher.
+type Event struct {
+	Op      Op     // What happened
+	Path    string // Path the event refers to
+	OldPath string // Previous path of a renamed entry
+}
+
+// WatchOptions controls the behaviour of a Watcher.
+type WatchOptions struct {
+	// Ops selects the operations to deliver. Zero delivers all of them.
+	// OpRescan is always delivered.
+	Ops Op
+	// Filter, if set, drops events whose path it rejects. New directories
+	// are watched regardless, so their contents can still match.
+	Filter func(path string) bool
+	// Debounce, if positive, holds events back until a path has been quiet
+	// for this long and merges the events it collected into one.
+	Debounce time.Duration
+	// NonRecursive watches only the entries directly inside root.
+	NonRecursive bool
+	// Poll makes NewWatcher use snapshot polling instead of inotify.
+	Poll bool
+	// PollInterval is the time between snapshots of a polling watcher.
+	// Defaults to one second.
+	PollInterval time.Duration
+}
+
+// Watcher delivers filesystem events for a directory tree.
+// Read events from Events and errors from Errors until Close is called.
+type Watcher struct {
+	opts      WatchOptions
+	events    chan Event
+	errors    chan error
+	done      chan struct{}
+	closeOnce sync.Once
+	stop      func() error
+}
+
+// NewWatcher watches root and every directory below it, including
+// directories created later. It uses inotify on Linux and falls back to
+// NewPollingWatcher where inotify is unavailable or opts.Poll is set.
+func NewWatcher(root string, opts WatchOptions) (*Watcher, error) {
+	if !opts.Poll {
+		w, err := newInotifyWatcher(root, opts)
+		if !errors.Is(err, ErrUnsupported) {
+			return w, err
+		}
+	}
+	return NewPollingWatcher(root, opts)
+}
+
+// newWatcher returns a Watcher and the channel its backend sends raw events to.
+// The backend must close the channel when it stops.
+func newWatcher(opts WatchOptions) (*Watcher, chan Event) {
+	w := &Watcher{
+		opts:   opts,
+		events: make(chan Event, 64),
+		errors: make(chan error, 8),
+		done:   make(chan struct{}),
+	}
+	raw := make(chan Event, 64)
+	go w.run(raw)
+	return w, raw
+}
+
+// Events returns the channel events are delivered on. It is closed once the
+// Watcher stops.
+func (w *Watcher) Events() <-chan Event {
+	return w.events
+}
+
+// Errors returns the channel non-fatal watch errors are delivered on.
+func (w *Watcher) Errors() <-chan error {
+	return w.errors
+}
+
+// Close stops the Watcher and releases its resources.
+func (w *Watcher) Close() error {
+	var err error
+	w.closeOnce.Do(func() {
+		close(w.done)
+		if w.stop != nil {
+			err = w.stop()
+		}
+	})
+	return err
+}
+
+// send passes a raw event from the backend on, unless the Watcher is closed.
+// It reports whether the backend should keep going.
+func (w *Watcher) send(raw chan<- Event, ev Event) bool {
+	if !w.wants(ev) {
+		return true
+	}
+	select {
+	case raw <- ev:
+		return true
+	case <-w.done:
+		return false
+	}
+}
+
+// sendError reports err without blocking the backend.
+func (w *Watcher) sendError(err error) {
+	select {
+	case w.errors <- err:
+	default:
+	}
+}
+
+// wants reports whether ev passes the Ops mask and the Filter.
+func (w *Watcher) wants(ev Event) bool {
+	if ev.Op&OpRescan != 0 {
+		return true
+	}
+	if w.opts.Ops != 0 && ev.Op&w.opts.Ops == 0 {
+		return false
+	}
+	if w.opts.Filter == nil {
+		return true
+	}
+	return w.opts.Filter(ev.Path) || (ev.OldPath != "" && w.opts.Filter(ev.OldPath))
+}
+
+// run delivers raw events to the Events channel, coalescing them per path
+// when a debounce interval is configured.
+func (w *Watcher) run(raw <-chan Event) {
+	defer close(w.events)
+	if w.opts.Debounce <= 0 {
+		for ev := range raw {
+			select {
+			case w.events <- ev:
+			case <-w.done:
+				return
+			}
+		}
+		return
+	}
+
+	type pendingEvent struct {
+		ev   Event
+		last time.Time
+	}
+	pending := make(map[string]*pendingEvent)
+	var order []string
+	flush := func(all bool) bool {
+		now := time.Now()
+		kept := order[:0]
+		for _, path := range order {
+			p := pending[path]
+			if !all && now.Sub(p.last) < w.opts.Debounce {
+				kept = append(kept, path)
+				continue
+			}
+			delete(pending, path)
+			select {
+			case w.events <- p.ev:
+			case <-w.done:
+				return false
+			}
+		}
+		order = kept
+		return true
+	}
+
+	tick := w.opts.Debounce / 2
+	if tick <= 0 {
+		tick = w.opts.Debounce
+	}
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+	for {
+		select {
+		case ev, ok := <-raw:
+			if !ok {
+				flush(true)
+				return
+			}
+			if p, seen := pending[ev.Path]; seen {
+				p.ev.Op |= ev.Op
+				if ev.OldPath != "" {
+					p.ev.OldPath = ev.OldPath
+				}
+				p.last = time.Now()
+				continue
+			}
+			pending[ev.Path] = &pendingEvent{ev: ev, last: time.Now()}
+			order = append(order, ev.Path)
+		case <-ticker.C:
+			if !flush(false) {
+				return
+			}
+		case <-w.done:
+			return
+		}
+	}
+}
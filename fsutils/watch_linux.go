@@ -0,0 +1,234 @@
+//go:build linux
+
+// Package fsutils provides filesystem utility functions for Go applications.
+package fsutils
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+// inotifyMask is the set of inotify events requested for every directory.
+const inotifyMask = syscall.IN_CREATE | syscall.IN_MODIFY | syscall.IN_ATTRIB | syscall.IN_DELETE |
+	syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO | syscall.IN_DELETE_SELF | syscall.IN_ONLYDIR
+
+// inotifyWatcher keeps one inotify watch per directory of the tree.
+// Its maps are only touched by the reading goroutine.
+type inotifyWatcher struct {
+	w     *Watcher
+	raw   chan Event
+	file  *os.File
+	fd    int
+	root  string
+	deep  bool           // watch subdirectories too
+	paths map[int]string // watch descriptor -> directory
+	wds   map[string]int // directory -> watch descriptor
+}
+
+// newInotifyWatcher starts an inotify-backed Watcher for root.
+func newInotifyWatcher(root string, opts WatchOptions) (*Watcher, error) {
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, &os.PathError{Op: "watch", Path: root, Err: syscall.ENOTDIR}
+	}
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, os.NewSyscallError("inotify_init1", err)
+	}
+	iw := &inotifyWatcher{
+		// A non-blocking descriptor is handled by the runtime poller, so
+		// closing the file interrupts a pending Read.
+		file:  os.NewFile(uintptr(fd), "inotify"),
+		fd:    fd,
+		root:  filepath.Clean(root),
+		deep:  !opts.NonRecursive,
+		paths: make(map[int]string),
+		wds:   make(map[string]int),
+	}
+	if err := iw.addTree(iw.root, false); err != nil {
+		iw.file.Close()
+		return nil, err
+	}
+	iw.w, iw.raw = newWatcher(opts)
+	iw.w.stop = iw.file.Close
+	go iw.loop()
+	return iw.w, nil
+}
+
+// addTree watches dir and every directory below it. With report set, a
+// create event is sent for every entry found, so files written into a new
+// directory before its watch existed are not missed.
+func (iw *inotifyWatcher) addTree(dir string, report bool) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path != dir && os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if report && path != dir && !iw.w.send(iw.raw, Event{Op: OpCreate, Path: path}) {
+			return filepath.SkipAll
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if !iw.deep && path != iw.root {
+			return filepath.SkipDir
+		}
+		wd, err := syscall.InotifyAddWatch(iw.fd, path, inotifyMask)
+		if err != nil {
+			if path != dir && (err == syscall.ENOENT || err == syscall.ENOTDIR) {
+				return nil
+			}
+			return &os.PathError{Op: "inotify_add_watch", Path: path, Err: err}
+		}
+		iw.paths[wd] = path
+		iw.wds[path] = wd
+		return nil
+	})
+}
+
+// dropTree removes the watches of dir and everything below it.
+func (iw *inotifyWatcher) dropTree(dir string) {
+	for path, wd := range iw.wds {
+		if path == dir || strings.HasPrefix(path, dir+string(filepath.Separator)) {
+			syscall.InotifyRmWatch(iw.fd, uint32(wd))
+			delete(iw.wds, path)
+			delete(iw.paths, wd)
+		}
+	}
+}
+
+// moveTree updates the recorded paths after a watched directory was renamed.
+func (iw *inotifyWatcher) moveTree(from, to string) {
+	for path, wd := range iw.wds {
+		if path == from || strings.HasPrefix(path, from+string(filepath.Separator)) {
+			moved := to + path[len(from):]
+			delete(iw.wds, path)
+			iw.wds[moved] = wd
+			iw.paths[wd] = moved
+		}
+	}
+}
+
+// loop reads and translates inotify events until the Watcher is closed.
+func (iw *inotifyWatcher) loop() {
+	defer close(iw.raw)
+	buf := make([]byte, 64*1024)
+	for {
+		n, err := iw.file.Read(buf)
+		if err != nil {
+			if !errors.Is(err, os.ErrClosed) {
+				iw.w.sendError(err)
+			}
+			return
+		}
+		if !iw.handle(buf[:n]) {
+			return
+		}
+	}
+}
+
+// handle translates one buffer of raw inotify events. A move is reported as
+// a rename when both halves arrive together; a lone IN_MOVED_FROM means the
+// entry left the tree and a lone IN_MOVED_TO means it entered it.
+// It reports whether the loop should continue.
+func (iw *inotifyWatcher) handle(buf []byte) bool {
+	type moveFrom struct {
+		path  string
+		isDir bool
+	}
+	moves := make(map[uint32]moveFrom)
+	var moveOrder []uint32
+
+	for off := 0; off+syscall.SizeofInotifyEvent <= len(buf); {
+		raw := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[off]))
+		nameBytes := buf[off+syscall.SizeofInotifyEvent : off+syscall.SizeofInotifyEvent+int(raw.Len)]
+		off += syscall.SizeofInotifyEvent + int(raw.Len)
+
+		mask := raw.Mask
+		if mask&syscall.IN_Q_OVERFLOW != 0 {
+			if err := iw.addTree(iw.root, false); err != nil {
+				iw.w.sendError(err)
+			}
+			if !iw.w.send(iw.raw, Event{Op: OpRescan, Path: iw.root}) {
+				return false
+			}
+			continue
+		}
+		dir, ok := iw.paths[int(raw.Wd)]
+		if !ok {
+			continue
+		}
+		if mask&syscall.IN_IGNORED != 0 {
+			delete(iw.paths, int(raw.Wd))
+			if iw.wds[dir] == int(raw.Wd) {
+				delete(iw.wds, dir)
+			}
+			continue
+		}
+		if mask&syscall.IN_DELETE_SELF != 0 {
+			continue // the parent directory reports the removal
+		}
+		path := filepath.Join(dir, strings.TrimRight(string(nameBytes), "\x00"))
+		isDir := mask&syscall.IN_ISDIR != 0
+
+		var ev Event
+		switch {
+		case mask&syscall.IN_CREATE != 0:
+			ev = Event{Op: OpCreate, Path: path}
+		case mask&syscall.IN_MODIFY != 0:
+			ev = Event{Op: OpWrite, Path: path}
+		case mask&syscall.IN_ATTRIB != 0:
+			ev = Event{Op: OpChmod, Path: path}
+		case mask&syscall.IN_DELETE != 0:
+			ev = Event{Op: OpRemove, Path: path}
+		case mask&syscall.IN_MOVED_FROM != 0:
+			moves[raw.Cookie] = moveFrom{path, isDir}
+			moveOrder = append(moveOrder, raw.Cookie)
+			continue
+		case mask&syscall.IN_MOVED_TO != 0:
+			if from, ok := moves[raw.Cookie]; ok {
+				delete(moves, raw.Cookie)
+				if isDir {
+					iw.moveTree(from.path, path)
+				}
+				ev = Event{Op: OpRename, Path: path, OldPath: from.path}
+			} else {
+				ev = Event{Op: OpCreate, Path: path}
+			}
+		default:
+			continue
+		}
+		if !iw.w.send(iw.raw, ev) {
+			return false
+		}
+		if isDir && ev.Op == OpCreate {
+			if err := iw.addTree(path, true); err != nil && !os.IsNotExist(err) {
+				iw.w.sendError(err)
+			}
+		}
+	}
+
+	for _, cookie := range moveOrder {
+		from, ok := moves[cookie]
+		if !ok {
+			continue
+		}
+		if from.isDir {
+			iw.dropTree(from.path)
+		}
+		if !iw.w.send(iw.raw, Event{Op: OpRemove, Path: from.path}) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,11 @@
+//go:build !linux
+
+// Package fsutils provides filesystem utility functions for Go applications.
+package fsutils
+
+import "os"
+
+// newInotifyWatcher is not available on this platform.
+func newInotifyWatcher(root string, opts WatchOptions) (*Watcher, error) {
+	return nil, &os.PathError{Op: "watch", Path: root, Err: ErrUnsupported}
+}
@@ -0,0 +1,163 @@
+// Package fsutils provides filesystem utility functions for Go applications.
+package fsutils
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// defaultPollInterval is the snapshot interval used when WatchOptions.PollInterval is zero.
+const defaultPollInterval = time.Second
+
+// snapshotEntry is what the polling watcher remembers about one path.
+type snapshotEntry struct {
+	size    int64
+	modTime time.Time
+	mode    fs.FileMode
+	dev     uint64
+	ino     uint64
+	hasIno  bool
+}
+
+// snapshot maps every path below a root to its metadata.
+type snapshot map[string]snapshotEntry
+
+// NewPollingWatcher watches root by taking a snapshot of the tree every
+// opts.PollInterval and diffing it against the previous one. It delivers the
+// same events as NewWatcher and works where inotify does not, such as on
+// network filesystems. Renames are recognised by inode where available;
+// elsewhere they are reported as a removal and a creation.
+func NewPollingWatcher(root string, opts WatchOptions) (*Watcher, error) {
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, &os.PathError{Op: "watch", Path: root, Err: os.ErrInvalid}
+	}
+	root = filepath.Clean(root)
+	prev, err := takeSnapshot(root, !opts.NonRecursive)
+	if err != nil {
+		return nil, err
+	}
+	interval := opts.PollInterval
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+
+	w, raw := newWatcher(opts)
+	go func() {
+		defer close(raw)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-w.done:
+				return
+			case <-ticker.C:
+			}
+			next, err := takeSnapshot(root, !opts.NonRecursive)
+			if err != nil {
+				w.sendError(err)
+				continue
+			}
+			for _, ev := range diffSnapshots(prev, next) {
+				if !w.send(raw, ev) {
+					return
+				}
+			}
+			prev = next
+		}
+	}()
+	return w, nil
+}
+
+// takeSnapshot records the metadata of every path below root, or only of the
+// entries directly inside it when deep is false.
+// Entries that vanish during the walk are skipped.
+func takeSnapshot(root string, deep bool) (snapshot, error) {
+	snap := make(snapshot)
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path != root && os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if path == root {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		entry := snapshotEntry{size: info.Size(), modTime: info.ModTime(), mode: info.Mode()}
+		entry.dev, entry.ino, entry.hasIno = fileDevIno(info)
+		snap[path] = entry
+		if d.IsDir() && !deep {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	return snap, err
+}
+
+// diffSnapshots turns the differences between two snapshots into events,
+// sorted by path. A removed and a created path sharing an inode become a rename;
+// several paths may be created for one inode, as hardlinks.
+func diffSnapshots(prev, next snapshot) []Event {
+	type inode struct{ dev, ino uint64 }
+	var events []Event
+	created := make(map[inode][]string)
+	for path, entry := range next {
+		old, existed := prev[path]
+		switch {
+		case !existed:
+			if entry.hasIno {
+				key := inode{entry.dev, entry.ino}
+				created[key] = append(created[key], path)
+			} else {
+				events = append(events, Event{Op: OpCreate, Path: path})
+			}
+		case entry.hasIno && old.hasIno && (entry.dev != old.dev || entry.ino != old.ino):
+			events = append(events, Event{Op: OpRemove | OpCreate, Path: path})
+		default:
+			var op Op
+			if !entry.mode.IsDir() && (entry.size != old.size || !entry.modTime.Equal(old.modTime)) {
+				op |= OpWrite
+			}
+			if entry.mode != old.mode {
+				op |= OpChmod
+			}
+			if op != 0 {
+				events = append(events, Event{Op: op, Path: path})
+			}
+		}
+	}
+	for path, entry := range prev {
+		if _, exists := next[path]; exists {
+			continue
+		}
+		key := inode{entry.dev, entry.ino}
+		if paths := created[key]; len(paths) > 0 && entry.hasIno {
+			sort.Strings(paths)
+			created[key] = paths[1:]
+			events = append(events, Event{Op: OpRename, Path: paths[0], OldPath: path})
+			continue
+		}
+		events = append(events, Event{Op: OpRemove, Path: path})
+	}
+	for _, paths := range created {
+		for _, path := range paths {
+			events = append(events, Event{Op: OpCreate, Path: path})
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Path < events[j].Path })
+	return events
+}
@@ -0,0 +1,182 @@
+package fsutils_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/utsav-56/go_fs_utils/fsutils"
+)
+
+// waitForEvent reads events from w until one carries op for path.
+func waitForEvent(t *testing.T, w *fsutils.Watcher, op fsutils.Op, path string) fsutils.Event {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case ev, ok := <-w.Events():
+			if !ok {
+				t.Fatalf("Watcher closed while waiting for %v on %s", op, path)
+			}
+			if ev.Op&op != 0 && ev.Path == path {
+				return ev
+			}
+		case <-timeout:
+			t.Fatalf("Timed out waiting for %v on %s", op, path)
+		}
+	}
+}
+
+func TestWatcher(t *testing.T) {
+	// Create a temporary directory for our tests
+	tempDir, err := os.MkdirTemp("", "fsutils-watch-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	w, err := fsutils.NewWatcher(tempDir, fsutils.WatchOptions{})
+	if err != nil {
+		t.Fatalf("NewWatcher failed: %v", err)
+	}
+	defer w.Close()
+
+	// Test create and write
+	file := filepath.Join(tempDir, "file.txt")
+	if err := fsutils.Touch(file); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	waitForEvent(t, w, fsutils.OpCreate, file)
+	if err := os.WriteFile(file, []byte("data"), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	waitForEvent(t, w, fsutils.OpWrite, file)
+
+	// Test new subdirectories are watched recursively
+	sub := filepath.Join(tempDir, "sub", "deeper")
+	if err := fsutils.Mkdir(sub); err != nil {
+		t.Fatalf("Failed to create test directory: %v", err)
+	}
+	waitForEvent(t, w, fsutils.OpCreate, filepath.Join(tempDir, "sub"))
+	nested := filepath.Join(sub, "nested.txt")
+	if err := fsutils.Touch(nested); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	waitForEvent(t, w, fsutils.OpCreate, nested)
+
+	// Test rename and remove
+	renamed := filepath.Join(tempDir, "renamed.txt")
+	if err := os.Rename(file, renamed); err != nil {
+		t.Fatalf("Failed to rename test file: %v", err)
+	}
+	ev := waitForEvent(t, w, fsutils.OpRename, renamed)
+	if ev.OldPath != file {
+		t.Errorf("Rename event has wrong old path: got %s, want %s", ev.OldPath, file)
+	}
+	if err := os.Remove(renamed); err != nil {
+		t.Fatalf("Failed to remove test file: %v", err)
+	}
+	waitForEvent(t, w, fsutils.OpRemove, renamed)
+
+	// Test Close ends the event stream
+	w.Close()
+	for range w.Events() {
+	}
+}
+
+func TestWatcherDebounce(t *testing.T) {
+	// Create a temporary directory for our tests
+	tempDir, err := os.MkdirTemp("", "fsutils-watch-debounce-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	opts := fsutils.WatchOptions{
+		Debounce: 100 * time.Millisecond,
+		Filter:   func(path string) bool { return filepath.Ext(path) == ".log" },
+	}
+	w, err := fsutils.NewWatcher(tempDir, opts)
+	if err != nil {
+		t.Fatalf("NewWatcher failed: %v", err)
+	}
+	defer w.Close()
+
+	// Several writes to one file and a filtered-out file
+	fsutils.Touch(filepath.Join(tempDir, "ignored.txt"))
+	logFile := filepath.Join(tempDir, "app.log")
+	for i := 0; i < 5; i++ {
+		if err := os.WriteFile(logFile, []byte{byte(i)}, 0644); err != nil {
+			t.Fatalf("Failed to write test file: %v", err)
+		}
+	}
+
+	ev := waitForEvent(t, w, fsutils.OpCreate, logFile)
+	if ev.Op&fsutils.OpWrite == 0 {
+		t.Errorf("Debounced event didn't merge the writes: %v", ev.Op)
+	}
+	select {
+	case ev := <-w.Events():
+		t.Errorf("Unexpected extra event: %v %s", ev.Op, ev.Path)
+	case <-time.After(300 * time.Millisecond):
+	}
+}
+
+func TestPollingWatcher(t *testing.T) {
+	// Create a temporary directory for our tests
+	tempDir, err := os.MkdirTemp("", "fsutils-watch-poll-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	w, err := fsutils.NewWatcher(tempDir, fsutils.WatchOptions{Poll: true, PollInterval: 20 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("NewWatcher failed: %v", err)
+	}
+	defer w.Close()
+
+	// Test create and modify
+	file := filepath.Join(tempDir, "file.txt")
+	if err := fsutils.Touch(file); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	waitForEvent(t, w, fsutils.OpCreate, file)
+	if err := os.WriteFile(file, []byte("grown"), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	waitForEvent(t, w, fsutils.OpWrite, file)
+
+	// Test renames are detected by inode
+	renamed := filepath.Join(tempDir, "renamed.txt")
+	if err := os.Rename(file, renamed); err != nil {
+		t.Fatalf("Failed to rename test file: %v", err)
+	}
+	ev := waitForEvent(t, w, fsutils.OpRename|fsutils.OpCreate, renamed)
+	if ev.Op != fsutils.OpRename || ev.OldPath != file {
+		t.Errorf("Rename gave a %v event from %q, want a rename from %s", ev.Op, ev.OldPath, file)
+	}
+
+	// Test hardlinks appearing together are each created
+	outside := t.TempDir()
+	linked := filepath.Join(outside, "linked")
+	fsutils.Mkdir(linked)
+	os.WriteFile(filepath.Join(outside, "shared.txt"), []byte("shared"), 0644)
+	for _, name := range []string{"a.txt", "b.txt"} {
+		if err := os.Link(filepath.Join(outside, "shared.txt"), filepath.Join(linked, name)); err != nil {
+			t.Skipf("hardlinks not supported: %v", err)
+		}
+	}
+	if err := os.Rename(linked, filepath.Join(tempDir, "linked")); err != nil {
+		t.Skipf("cannot move into the watched directory: %v", err)
+	}
+	waitForEvent(t, w, fsutils.OpCreate, filepath.Join(tempDir, "linked", "a.txt"))
+	waitForEvent(t, w, fsutils.OpCreate, filepath.Join(tempDir, "linked", "b.txt"))
+
+	// Test remove
+	if err := os.Remove(renamed); err != nil {
+		t.Fatalf("Failed to remove test file: %v", err)
+	}
+	waitForEvent(t, w, fsutils.OpRemove, renamed)
+}